@@ -3,24 +3,43 @@ package gosoup
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
+	"maps"
+	"slices"
 	"strings"
+	"sync"
 
 	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
 )
 
-// Corresponds to HTML document
+// Corresponds to HTML document.
+// Tags of a document may be read and searched from multiple goroutines,
+// but modifying the tree concurrently is not safe
 type Document struct {
+	node *html.Node
 	root *html.Node
 	cache map[*html.Node]*Tag
+	cacheMu sync.RWMutex
 }
 
-// Return root tag
+// Return root tag. When root is removed or replaced, the first element
+// left in the document becomes the root, so nil is returned only
+// if no elements are left
 func (doc *Document) Root() *Tag {
 	return doc.newTag(doc.root)
 }
 
-// Parse HTML document from given reader and return root tag.
+// Render a whole document, including doctype
+func (doc *Document) String() string {
+	var builder strings.Builder
+	html.Render(&builder, doc.node)
+	return builder.String()
+}
+
+// Parse HTML document from given reader and return the document.
 // Since Parse() from the golang.org/x/net/html library is used internally,
 // the rules for basic Parse also apply for this function:
 //
@@ -28,7 +47,7 @@ func (doc *Document) Root() *Tag {
 //
 // * "The input is assumed to be UTF-8 encoded."
 func Parse(reader io.Reader) (*Document, error) {
-	root, err := html.Parse(reader)
+	root, err := parseHTML(reader)
 	if err != nil {
 		return nil, err
 	}
@@ -36,7 +55,164 @@ func Parse(reader io.Reader) (*Document, error) {
 	return getDocument(root)
 }
 
-// Parse given HTML document bytes and return root tag.
+// Option changing behavior of ParseReader
+type ParseOption func(*parseOptions)
+
+type parseOptions struct {
+	detectCharset bool
+	contentType   string
+	maxDepth      int
+}
+
+// Detect encoding of input and transcode it to UTF-8 before parsing.
+// Encoding is determined from byte order mark, Content-Type hint
+// given by WithContentType or <meta> tag of the document
+func WithCharsetDetection() ParseOption {
+	return func(opts *parseOptions) {
+		opts.detectCharset = true
+	}
+}
+
+// Use value of Content-Type header as a hint for charset detection
+func WithContentType(contentType string) ParseOption {
+	return func(opts *parseOptions) {
+		opts.contentType = contentType
+	}
+}
+
+// Reject documents with elements nested deeper than given depth,
+// returning ParseError wrapping ErrTooDeep. Depth is counted as in
+// Tag.Depth, so <html> has depth 0. The check runs after the whole
+// document is parsed, so it limits the resulting tree, not parsing work.
+// Limit cannot be raised this way: documents nested deeper than
+// 512 elements are always rejected by the parser
+func WithMaxDepth(depth int) ParseOption {
+	return func(opts *parseOptions) {
+		opts.maxDepth = depth
+	}
+}
+
+// Parse HTML document from given reader with given options.
+// Without options it behaves like Parse, so the input is assumed
+// to be UTF-8 encoded unless WithCharsetDetection is given
+func ParseReader(reader io.Reader, opts ...ParseOption) (*Document, error) {
+	options := &parseOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if options.detectCharset {
+		utf8Reader, err := charset.NewReader(reader, options.contentType)
+		if err != nil {
+			return nil, err
+		}
+		reader = utf8Reader
+	}
+
+	counter := &countingReader{reader: reader}
+
+	root, err := parseHTML(counter)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.maxDepth > 0 && maxDepth(root) > options.maxDepth {
+		return nil, &ParseError{
+			Offset: counter.count,
+			Err:    fmt.Errorf("%w: nesting exceeds %d elements", ErrTooDeep, options.maxDepth),
+		}
+	}
+
+	return getDocument(root)
+}
+
+// Returned when document is nested deeper than parser allows
+var ErrTooDeep = errors.New("document is nested too deeply")
+
+// Returned when input has no content: it is empty, whitespace only or
+// consists of empty <html>, <head> and <body> tags the parser would
+// imply anyway
+var ErrNoElement = errors.New("no element node found")
+
+// Failure of parsing HTML document or fragment.
+// Offset is a number of input bytes read by the parser when it failed,
+// so it points at or after the actual problem. Context is the name of
+// context tag for fragments and empty for whole documents
+type ParseError struct {
+	Offset  int64
+	Context string
+	Err     error
+}
+
+func (e *ParseError) Error() string {
+	if e.Context != "" {
+		return fmt.Sprintf("parse fragment in <%s> at offset %d: %v", e.Context, e.Offset, e.Err)
+	}
+	return fmt.Sprintf("parse document at offset %d: %v", e.Offset, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Parse HTML document, wrapping failures into ParseError
+func parseHTML(reader io.Reader) (*html.Node, error) {
+	counter := &countingReader{reader: reader}
+
+	root, err := html.Parse(counter)
+	if err != nil {
+		return nil, newParseError(err, counter.count, "")
+	}
+	return root, nil
+}
+
+// Parse HTML fragment in context of node, wrapping failures into ParseError
+func parseHTMLFragment(reader io.Reader, context *html.Node) ([]*html.Node, error) {
+	counter := &countingReader{reader: reader}
+
+	nodes, err := html.ParseFragment(counter, context)
+	if err != nil {
+		return nil, newParseError(err, counter.count, context.Data)
+	}
+	return nodes, nil
+}
+
+// Wrap error of golang.org/x/net/html parser into ParseError,
+// reporting nesting limit errors as ErrTooDeep
+func newParseError(err error, offset int64, context string) *ParseError {
+	if strings.Contains(err.Error(), "open stack of elements exceeds") {
+		err = fmt.Errorf("%w: nesting exceeds 512 elements", ErrTooDeep)
+	}
+	return &ParseError{Offset: offset, Context: context, Err: err}
+}
+
+// Reader that counts read bytes
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
+
+// Get the greatest depth of element nodes in a tree, root element
+// having depth 0. Returns -1 if there are no elements
+func maxDepth(node *html.Node) int {
+	deepest := -1
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		depth := maxDepth(child)
+		if child.Type == html.ElementNode {
+			depth++
+		}
+		deepest = max(deepest, depth)
+	}
+	return deepest
+}
+
+// Parse given HTML document bytes and return the document.
 // Since Parse() from the golang.org/x/net/html library is used internally,
 // the rules for basic Parse also apply for this function:
 //
@@ -44,7 +220,7 @@ func Parse(reader io.Reader) (*Document, error) {
 //
 // * "The input is assumed to be UTF-8 encoded."
 func ParseBytes(content []byte) (*Document, error) {
-	root, err := html.Parse(bytes.NewReader(content))
+	root, err := parseHTML(bytes.NewReader(content))
 	if err != nil {
 		return nil, err
 	}
@@ -52,7 +228,7 @@ func ParseBytes(content []byte) (*Document, error) {
 	return getDocument(root)
 }
 
-// Parse given HTML document string and return root tag.
+// Parse given HTML document string and return the document.
 // Since Parse() from the golang.org/x/net/html library is used internally,
 // the rules for basic Parse also apply for this function:
 //
@@ -60,7 +236,7 @@ func ParseBytes(content []byte) (*Document, error) {
 //
 // * "The input is assumed to be UTF-8 encoded."
 func ParseString(content string) (*Document, error) {
-	root, err := html.Parse(strings.NewReader(content))
+	root, err := parseHTML(strings.NewReader(content))
 	if err != nil {
 		return nil, err
 	}
@@ -68,14 +244,103 @@ func ParseString(content string) (*Document, error) {
 	return getDocument(root)
 }
 
+// Parse HTML fragment from given reader in context of given tag
+// and return top-level parsed tags. Context determines parsing rules,
+// e.g. <tr> fragment is only parsed as table row in <tbody> context.
+// Parsed tags belong to a new document, root of which is the first of them
+func ParseFragment(reader io.Reader, context *Tag) ([]*Tag, error) {
+	if context == nil || context.node == nil || context.node.Type != html.ElementNode {
+		return nil, errors.New("fragment context must be an element tag")
+	}
+
+	nodes, err := parseHTMLFragment(reader, context.node)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &html.Node{Type: html.DocumentNode}
+	for _, node := range nodes {
+		root.AppendChild(node)
+	}
+
+	doc := &Document{
+		node: root,
+		root: findElementNode(root),
+		cache: make(map[*html.Node]*Tag),
+	}
+
+	var tags []*Tag
+	for _, node := range nodes {
+		if node.Type == html.ElementNode {
+			tags = append(tags, doc.newTag(node))
+		}
+	}
+
+	return tags, nil
+}
+
+// Create new tag with given name and attributes, not attached to any tree.
+// Attributes are rendered sorted by key. The tag can be inserted into
+// a document with AppendChild, InsertBefore, etc.
+func NewTag(name string, attrs map[string]string) *Tag {
+	node := &html.Node{
+		Type: html.ElementNode,
+		DataAtom: atom.Lookup([]byte(name)),
+		Data: name,
+	}
+
+	keys := slices.Sorted(maps.Keys(attrs))
+	for _, key := range keys {
+		node.Attr = append(node.Attr, html.Attribute{Key: key, Val: attrs[key]})
+	}
+
+	return detachedTag(node)
+}
+
+// Wrap existing element node of golang.org/x/net/html tree into a tag.
+// Returns nil for non-element nodes. Each call creates a new document
+// for the whole tree containing the node, so tags obtained from
+// different calls are not the same even for the same node
+func FromNode(node *html.Node) *Tag {
+	if node == nil || node.Type != html.ElementNode {
+		return nil
+	}
+
+	top := node
+	for top.Parent != nil {
+		top = top.Parent
+	}
+
+	doc := &Document{
+		node: top,
+		root: findElementNode(top),
+		cache: make(map[*html.Node]*Tag),
+	}
+
+	return doc.newTag(node)
+}
+
+// Create tag for a node that is not attached to any tree,
+// backed by its own document
+func detachedTag(node *html.Node) *Tag {
+	doc := &Document{
+		node: node,
+		root: node,
+		cache: make(map[*html.Node]*Tag),
+	}
+
+	return doc.newTag(node)
+}
+
 // Finding root element node (tag) of HTML document
 func getDocument(root *html.Node) (*Document, error) {
 	rootElement := findElementNode(root)
-	if rootElement == nil {
-		return nil, errors.New("no element node found")
+	if rootElement == nil || isEmptyDocument(root) {
+		return nil, ErrNoElement
 	}
 
 	doc := &Document{
+		node: root,
 		root: rootElement,
 		cache: make(map[*html.Node]*Tag),
 	}
@@ -83,6 +348,31 @@ func getDocument(root *html.Node) (*Document, error) {
 	return doc, nil
 }
 
+// Check whether parsed tree is only the skeleton implied for empty input:
+// <html>, <head> and <body> without attributes, holding whitespace at most
+func isEmptyDocument(node *html.Node) bool {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		switch child.Type {
+		case html.TextNode:
+			if strings.Trim(child.Data, " \t\n\f\r") != "" {
+				return false
+			}
+		case html.ElementNode:
+			switch child.Data {
+			case "html", "head", "body":
+			default:
+				return false
+			}
+			if len(child.Attr) > 0 || !isEmptyDocument(child) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // Finding first element node from given root
 func findElementNode(node *html.Node) *html.Node {
 	if node.Type == html.ElementNode {
@@ -106,16 +396,29 @@ func (doc *Document) newTag(node *html.Node) *Tag {
 		return nil
 	}
 
+	doc.cacheMu.RLock()
+	tag, ok := doc.cache[node]
+	doc.cacheMu.RUnlock()
+	if ok {
+		return tag
+	}
+
+	doc.cacheMu.Lock()
+	defer doc.cacheMu.Unlock()
+
 	if tag, ok := doc.cache[node]; ok {
 		return tag
 	}
 
+	// Attrs is built eagerly: it is an exported field read directly
+	// by callers, so there is no access to hook lazy population into.
+	// Searches avoid this cost for unmatched nodes via nodeMatcher
 	attrs := make(map[string]string, len(node.Attr))
 	for _, attr := range node.Attr {
 		attrs[attr.Key] = attr.Val
 	}
 
-	tag := &Tag{
+	tag = &Tag{
 		Name: node.Data, 
 		Attrs: attrs, 
 		node: node, 
@@ -126,8 +429,138 @@ func (doc *Document) newTag(node *html.Node) *Tag {
 	return tag
 }
 
-// Removes given tag from DOM tree and cache
+// Checks element nodes against predicate without creating tags
+// for nodes that don't match
+type nodeMatcher struct {
+	doc       *Document
+	predicate Predicate
+	scratch   Tag
+}
+
+func (doc *Document) newMatcher(predicate Predicate) *nodeMatcher {
+	return &nodeMatcher{
+		doc: doc,
+		predicate: predicate,
+		scratch: Tag{Attrs: make(map[string]string), doc: doc},
+	}
+}
+
+// Report whether given node matches predicate without creating a tag.
+// Cached tag is checked if there is one, otherwise predicate gets
+// scratch tag reused for every such node
+func (m *nodeMatcher) test(node *html.Node) bool {
+	m.doc.cacheMu.RLock()
+	tag, ok := m.doc.cache[node]
+	m.doc.cacheMu.RUnlock()
+	if ok {
+		return m.predicate(tag)
+	}
+
+	m.scratch.Name = node.Data
+	m.scratch.node = node
+	clear(m.scratch.Attrs)
+	for _, attr := range node.Attr {
+		m.scratch.Attrs[attr.Key] = attr.Val
+	}
+
+	return m.predicate(&m.scratch)
+}
+
+// Returns tag of given node if it matches predicate, nil otherwise
+func (m *nodeMatcher) match(node *html.Node) *Tag {
+	if m.test(node) {
+		return m.doc.newTag(node)
+	}
+	return nil
+}
+
+// Walk element descendants of given node in document order, passing
+// each one with its depth below given node, direct children having
+// depth 1. Visit reports whether to descend into the element and
+// whether to continue the walk. Returns false if the walk was stopped
+func walkElements(node *html.Node, visit func(node *html.Node, depth int) (descend, more bool)) bool {
+	var walk func(*html.Node, int) bool
+	walk = func(node *html.Node, depth int) bool {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			descend, more := visit(child, depth)
+			if !more {
+				return false
+			}
+			if descend && !walk(child, depth+1) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return walk(node, 1)
+}
+
+// Creates new Node from a given node, returns nil for unsupported node types
+func (doc *Document) newNode(node *html.Node) Node {
+	switch node.Type {
+	case html.ElementNode:
+		return doc.newTag(node)
+	case html.TextNode:
+		return NavigableString{Text: node.Data}
+	}
+	return nil
+}
+
+// Removes given tag from DOM tree and cache. If it was the root,
+// the first element left in the document becomes the new root
 func (doc *Document) removeTag(tag *Tag) {
 	tag.node.Parent.RemoveChild(tag.node)
+	if tag.node == doc.root {
+		doc.root = findElementNode(doc.node)
+	}
+
+	doc.cacheMu.Lock()
 	delete(doc.cache, tag.node)
+	doc.cacheMu.Unlock()
+}
+
+// Detaches given tag from its tree and moves it with all cached
+// descendants into document cache, so it can be attached to this document
+func (doc *Document) adoptTag(tag *Tag) {
+	if tag.node.Parent != nil {
+		tag.node.Parent.RemoveChild(tag.node)
+	}
+
+	old := tag.doc
+	if old != doc {
+		var moved []*Tag
+
+		old.cacheMu.Lock()
+		var collect func(*html.Node)
+		collect = func(node *html.Node) {
+			if cached, ok := old.cache[node]; ok {
+				delete(old.cache, node)
+				moved = append(moved, cached)
+			}
+			for child := node.FirstChild; child != nil; child = child.NextSibling {
+				collect(child)
+			}
+		}
+		collect(tag.node)
+		old.cacheMu.Unlock()
+
+		for _, cached := range moved {
+			cached.doc = doc
+		}
+		tag.doc = doc
+
+		doc.cacheMu.Lock()
+		for _, cached := range moved {
+			doc.cache[cached.node] = cached
+		}
+		doc.cacheMu.Unlock()
+	}
+
+	doc.cacheMu.Lock()
+	doc.cache[tag.node] = tag
+	doc.cacheMu.Unlock()
 }
@@ -1,8 +1,14 @@
 package gosoup
 
 import (
+	"errors"
+	"io"
 	"strings"
+	"sync"
 	"testing"
+	"testing/iotest"
+
+	"golang.org/x/net/html"
 )
 
 func TestParseString(t *testing.T) {
@@ -280,3 +286,309 @@ func TestDepth(t *testing.T) {
 		t.Fatalf("expected strong depth to be 4, got %d", strong.Depth())
 	}
 }
+
+func TestDocumentString(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("ParseString error: %v", err)
+	}
+
+	text := doc.String()
+	if !strings.HasPrefix(text, "<!DOCTYPE html>") {
+		t.Fatalf("expected document to start with doctype, got: %s", text)
+	}
+	if !strings.Contains(text, doc.Root().String()) {
+		t.Fatalf("expected document to contain rendered root, got: %s", text)
+	}
+}
+
+func TestParseFragment(t *testing.T) {
+	doc, err := ParseString(`<table><tbody><tr><td>Old</td></tr></tbody></table><ul></ul>`)
+	if err != nil {
+		t.Fatalf("ParseString error: %v", err)
+	}
+
+	root := doc.Root()
+
+	tbody := root.Find(HasName("tbody"))
+	tags, err := ParseFragment(strings.NewReader(`<tr><td>One</td></tr><tr><td>Two</td></tr>`), tbody)
+	if err != nil {
+		t.Fatalf("ParseFragment error: %v", err)
+	}
+	if len(tags) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(tags))
+	}
+	for i, expected := range []string{"One", "Two"} {
+		if tags[i].Name != "tr" {
+			t.Fatalf("expected 'tr' at index %d, got %q", i, tags[i].Name)
+		}
+		if td := tags[i].Find(HasName("td")); td == nil || td.Text() != expected {
+			t.Fatalf("expected cell %q at index %d", expected, i)
+		}
+		if tags[i].Parent() != nil {
+			t.Fatalf("expected top-level fragment tag to have no parent")
+		}
+	}
+
+	ul := root.Find(HasName("ul"))
+	tags, err = ParseFragment(strings.NewReader(`<li>one</li> <li>two</li>`), ul)
+	if err != nil {
+		t.Fatalf("ParseFragment error: %v", err)
+	}
+	if len(tags) != 2 || tags[0].Name != "li" || tags[1].Next() != nil {
+		t.Fatalf("expected two top-level li tags, got %v", tags)
+	}
+}
+
+func TestParseFragmentInvalidContext(t *testing.T) {
+	if _, err := ParseFragment(strings.NewReader(`<li>one</li>`), nil); err == nil {
+		t.Fatalf("expected error for nil context")
+	}
+	if _, err := ParseFragment(strings.NewReader(`<li>one</li>`), &Tag{Name: "ul"}); err == nil {
+		t.Fatalf("expected error for context without node")
+	}
+}
+
+func TestParseReaderCharsetDetection(t *testing.T) {
+	content := "<html><head><meta charset=\"windows-1252\"></head><body><p>Caf\xe9 cr\xe8me</p></body></html>"
+
+	doc, err := ParseReader(strings.NewReader(content), WithCharsetDetection())
+	if err != nil {
+		t.Fatalf("ParseReader error: %v", err)
+	}
+	if text := doc.Root().Find(HasName("p")).Text(); text != "Café crème" {
+		t.Fatalf("expected 'Café crème', got %q", text)
+	}
+
+	doc, err = ParseReader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ParseReader error: %v", err)
+	}
+	if text := doc.Root().Find(HasName("p")).Text(); text == "Café crème" {
+		t.Fatalf("expected input to be treated as UTF-8 without charset detection")
+	}
+}
+
+func TestParseReaderContentType(t *testing.T) {
+	content := "<p>Caf\xe9</p>"
+
+	doc, err := ParseReader(
+		strings.NewReader(content),
+		WithCharsetDetection(),
+		WithContentType("text/html; charset=ISO-8859-1"),
+	)
+	if err != nil {
+		t.Fatalf("ParseReader error: %v", err)
+	}
+	if text := doc.Root().Find(HasName("p")).Text(); text != "Café" {
+		t.Fatalf("expected 'Café', got %q", text)
+	}
+}
+
+func TestParseTooDeep(t *testing.T) {
+	content := strings.Repeat("<div>", 600) + strings.Repeat("</div>", 600)
+
+	if _, err := ParseString(content); !errors.Is(err, ErrTooDeep) {
+		t.Fatalf("expected ErrTooDeep, got %v", err)
+	}
+	if _, err := ParseReader(strings.NewReader(content)); !errors.Is(err, ErrTooDeep) {
+		t.Fatalf("expected ErrTooDeep from ParseReader, got %v", err)
+	}
+	if _, err := ParseString("<p>not a depth problem</p>"); err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	errRead := errors.New("connection reset")
+	reader := io.MultiReader(strings.NewReader("<p>partial"), iotest.ErrReader(errRead))
+
+	_, err := Parse(reader)
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected ParseError, got %v", err)
+	}
+	if parseErr.Offset != 10 || parseErr.Context != "" {
+		t.Fatalf("expected offset 10 without context, got %d and %q", parseErr.Offset, parseErr.Context)
+	}
+	if !errors.Is(err, errRead) {
+		t.Fatalf("expected original error to be unwrapped, got %v", err)
+	}
+
+	doc, err := ParseString("<table><tbody></tbody></table>")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	tbody := doc.Root().Find(HasName("tbody"))
+
+	_, err = ParseFragment(iotest.ErrReader(errRead), tbody)
+	if !errors.As(err, &parseErr) || parseErr.Context != "tbody" {
+		t.Fatalf("expected ParseError with tbody context, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "<tbody>") {
+		t.Fatalf("expected context in error message, got %q", err.Error())
+	}
+
+	_, err = ParseString(strings.Repeat("<div>", 600))
+	if !errors.As(err, &parseErr) || !errors.Is(err, ErrTooDeep) {
+		t.Fatalf("expected ParseError wrapping ErrTooDeep, got %v", err)
+	}
+}
+
+func TestNoElement(t *testing.T) {
+	if _, err := getDocument(&html.Node{Type: html.DocumentNode}); !errors.Is(err, ErrNoElement) {
+		t.Fatalf("expected ErrNoElement, got %v", err)
+	}
+
+	for _, content := range []string{"", "  \n\t", "<html><head></head><body> </body></html>"} {
+		if _, err := ParseString(content); !errors.Is(err, ErrNoElement) {
+			t.Fatalf("expected ErrNoElement for %q, got %v", content, err)
+		}
+		if _, err := ParseReader(strings.NewReader(content)); !errors.Is(err, ErrNoElement) {
+			t.Fatalf("expected ErrNoElement from ParseReader for %q, got %v", content, err)
+		}
+	}
+
+	for _, content := range []string{"text", "<!-- note -->", `<html lang="en"></html>`, "<p></p>"} {
+		doc, err := ParseString(content)
+		if err != nil {
+			t.Fatalf("Parse error for %q: %v", content, err)
+		}
+		if root := doc.Root(); root == nil || root.Name != "html" {
+			t.Fatalf("expected html root for %q, got %v", content, root)
+		}
+	}
+}
+
+func TestParseReaderMaxDepth(t *testing.T) {
+	// html, body and three divs make the deepest div have depth 4
+	content := "<div><div><div>deep</div></div></div>"
+
+	_, err := ParseReader(strings.NewReader(content), WithMaxDepth(3))
+	if !errors.Is(err, ErrTooDeep) {
+		t.Fatalf("expected ErrTooDeep, got %v", err)
+	}
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expected ParseError, got %T", err)
+	}
+	if parseErr.Offset != int64(len(content)) || parseErr.Context != "" {
+		t.Fatalf("unexpected ParseError fields: %+v", parseErr)
+	}
+
+	_, err = ParseReader(strings.NewReader(strings.Repeat("<div>", 600)), WithMaxDepth(1000))
+	if !errors.Is(err, ErrTooDeep) || !errors.As(err, &parseErr) {
+		t.Fatalf("expected ParseError wrapping ErrTooDeep past parser limit, got %v", err)
+	}
+
+	doc, err := ParseReader(strings.NewReader(content), WithMaxDepth(4))
+	if err != nil {
+		t.Fatalf("ParseReader error: %v", err)
+	}
+	if depth := doc.Root().FindAll(HasName("div"))[2].Depth(); depth != 4 {
+		t.Fatalf("expected deepest div at depth 4, got %d", depth)
+	}
+}
+
+func TestDocumentConcurrentAccess(t *testing.T) {
+	doc, err := ParseString("<div>" + strings.Repeat(`<p class="a"><span>item</span></p>`, 200) + "</div>")
+	if err != nil {
+		t.Fatalf("ParseString error: %v", err)
+	}
+
+	root := doc.Root()
+
+	var wg sync.WaitGroup
+	results := make([][]*Tag, 8)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = root.FindAll(HasName("span"))
+		}()
+	}
+	wg.Wait()
+
+	for i, found := range results {
+		if len(found) != 200 {
+			t.Fatalf("goroutine %d: expected 200 spans, got %d", i, len(found))
+		}
+		for j := range found {
+			if found[j] != results[0][j] {
+				t.Fatalf("goroutine %d: expected cached tag at index %d", i, j)
+			}
+		}
+	}
+}
+
+func TestNewTag(t *testing.T) {
+	tag := NewTag("a", map[string]string{"href": "/home", "class": "nav"})
+
+	if tag.Name != "a" {
+		t.Fatalf("expected tag name 'a', got %q", tag.Name)
+	}
+	if tag.Attrs["href"] != "/home" {
+		t.Fatalf("expected href '/home', got %q", tag.Attrs["href"])
+	}
+	if text := tag.String(); text != `<a class="nav" href="/home"></a>` {
+		t.Fatalf("unexpected rendering of new tag: %s", text)
+	}
+	if tag.Parent() != nil {
+		t.Fatalf("expected new tag to have no parent")
+	}
+
+	if text := NewTag("br", nil).String(); text != `<br/>` {
+		t.Fatalf("unexpected rendering of new void tag: %s", text)
+	}
+}
+
+func TestFromNode(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<div id="a"><p>Text</p></div>`))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	div := root.FirstChild.LastChild.FirstChild
+	tag := FromNode(div)
+	if tag == nil || tag.Name != "div" || tag.Attrs["id"] != "a" {
+		t.Fatalf("expected div tag, got %v", tag)
+	}
+	if tag.Node() != div {
+		t.Fatalf("expected Node to return wrapped node")
+	}
+	if p := tag.Find(HasName("p")); p == nil || p.Text() != "Text" {
+		t.Fatalf("expected wrapped tag to be searchable")
+	}
+	if parent := tag.Parent(); parent == nil || parent.Name != "body" {
+		t.Fatalf("expected wrapped tag to keep its parent, got %v", parent)
+	}
+
+	if tag := FromNode(root); tag != nil {
+		t.Fatalf("expected nil for document node, got %v", tag)
+	}
+	if tag := FromNode(nil); tag != nil {
+		t.Fatalf("expected nil for nil node, got %v", tag)
+	}
+}
+
+func TestNewTagInsert(t *testing.T) {
+	doc, err := ParseString(`<ul><li>one</li></ul>`)
+	if err != nil {
+		t.Fatalf("ParseString error: %v", err)
+	}
+
+	ul := doc.Root().Find(HasName("ul"))
+	li := NewTag("li", map[string]string{"id": "two"})
+	if err := ul.AppendChild(li); err != nil {
+		t.Fatalf("AppendChild error: %v", err)
+	}
+	li.AppendChild(NewTag("b", nil))
+
+	if text := ul.String(); text != `<ul><li>one</li><li id="two"><b></b></li></ul>` {
+		t.Fatalf("unexpected rendering after inserting new tags: %s", text)
+	}
+	if doc.Root().Find(HasID("two")) != li {
+		t.Fatalf("expected new tag to be reachable from the document")
+	}
+}
@@ -3,3 +3,5 @@ module github.com/fokitto/gosoup
 go 1.25.7
 
 require golang.org/x/net v0.50.0
+
+require golang.org/x/text v0.34.0 // indirect
@@ -3,16 +3,50 @@ package gosoup
 import (
 	"regexp"
 	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
 )
 
+// Condition on a tag used by search methods.
+// Search methods panic with "gosoup: nil predicate" if given nil predicate.
+// Predicate must not keep the tag it is given after returning:
+// to avoid allocations, search methods may pass the same scratch tag
+// for different nodes, and only tags of matched nodes are kept.
+// For the same reason the given tag must not be compared by identity
+// with other tags, e.g. t == t.Parent().FirstChild() may be false
 type Predicate func(*Tag) bool
 
+// Panics with a clear message instead of failing deep in traversal
+func checkPredicate(predicate Predicate) {
+	if predicate == nil {
+		panic("gosoup: nil predicate")
+	}
+}
+
 func HasName(name string) Predicate {
 	return func(tag *Tag) bool {
 		return tag.Name == name
 	}
 }
 
+func HasNameFold(name string) Predicate {
+	return func(tag *Tag) bool {
+		return strings.EqualFold(tag.Name, name)
+	}
+}
+
+func HasAnyName(names ...string) Predicate {
+	return func(tag *Tag) bool {
+		for _, name := range names {
+			if tag.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+}
+
 func HasAttr(attr string) Predicate {
 	return func(tag *Tag) bool {
 		_, ok := tag.Attrs[attr]
@@ -42,12 +76,49 @@ func HasClass(class string) Predicate {
 	}
 }
 
+func HasClassFold(class string) Predicate {
+	return func(tag *Tag) bool {
+		tagClass, ok := tag.Attrs["class"]
+		if !ok {
+			return false
+		}
+		for _, entry := range strings.Split(tagClass, " ") {
+			if strings.EqualFold(entry, class) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func HasClassAll(classes ...string) Predicate {
+	predicates := make([]Predicate, len(classes))
+	for i, class := range classes {
+		predicates[i] = HasClass(class)
+	}
+	return All(predicates...)
+}
+
+func HasClassAny(classes ...string) Predicate {
+	predicates := make([]Predicate, len(classes))
+	for i, class := range classes {
+		predicates[i] = HasClass(class)
+	}
+	return Any(predicates...)
+}
+
 func HasNoClass() Predicate {
 	return func(tag *Tag) bool {
 		return HasNoAttr("class")(tag)
 	}
 }
 
+func HasID(id string) Predicate {
+	return func(tag *Tag) bool {
+		return AttrEq("id", id)(tag)
+	}
+}
+
 func AttrEq(attr string, value string) Predicate {
 	return func(tag *Tag) bool {
 		if tagAttr, ok := tag.Attrs[attr]; ok {
@@ -57,6 +128,15 @@ func AttrEq(attr string, value string) Predicate {
 	}
 }
 
+func AttrEqFold(attr string, value string) Predicate {
+	return func(tag *Tag) bool {
+		if tagAttr, ok := tag.Attrs[attr]; ok {
+			return strings.EqualFold(tagAttr, value)
+		}
+		return false
+	}
+}
+
 func AttrContains(attr string, substr string) Predicate {
 	return func(tag *Tag) bool {
 		if tagAttr, ok := tag.Attrs[attr]; ok {
@@ -66,6 +146,24 @@ func AttrContains(attr string, substr string) Predicate {
 	}
 }
 
+func AttrStartsWith(attr string, prefix string) Predicate {
+	return func(tag *Tag) bool {
+		if tagAttr, ok := tag.Attrs[attr]; ok {
+			return strings.HasPrefix(tagAttr, prefix)
+		}
+		return false
+	}
+}
+
+func AttrEndsWith(attr string, suffix string) Predicate {
+	return func(tag *Tag) bool {
+		if tagAttr, ok := tag.Attrs[attr]; ok {
+			return strings.HasSuffix(tagAttr, suffix)
+		}
+		return false
+	}
+}
+
 func AttrMatch(attr string, pattern *regexp.Regexp) Predicate {
 	return func(tag *Tag) bool {
 		if tagAttr, ok := tag.Attrs[attr]; ok {
@@ -75,6 +173,17 @@ func AttrMatch(attr string, pattern *regexp.Regexp) Predicate {
 	}
 }
 
+func AttrKeyMatch(pattern *regexp.Regexp) Predicate {
+	return func(tag *Tag) bool {
+		for key := range tag.Attrs {
+			if pattern.MatchString(key) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
 func All(predicates ...Predicate) Predicate {
 	return func(tag *Tag) bool {
 		for _, predicate := range predicates {
@@ -96,3 +205,174 @@ func Any(predicates ...Predicate) Predicate {
 		return false
 	}
 }
+
+// Inverts predicate. Never matches nil tag
+func Not(predicate Predicate) Predicate {
+	return func(tag *Tag) bool {
+		if tag == nil {
+			return false
+		}
+		return !predicate(tag)
+	}
+}
+
+
+// Matches tags whose trimmed direct text equals given string
+func TextEq(text string) Predicate {
+	return func(tag *Tag) bool {
+		return strings.TrimSpace(tag.Text()) == text
+	}
+}
+
+// Matches tags whose trimmed direct text contains given substring
+func TextContains(substr string) Predicate {
+	return func(tag *Tag) bool {
+		return strings.Contains(strings.TrimSpace(tag.Text()), substr)
+	}
+}
+
+// Matches tags whose text, including inner tags, contains given substring
+func FullTextContains(substr string) Predicate {
+	return func(tag *Tag) bool {
+		return strings.Contains(tag.FullText(), substr)
+	}
+}
+
+// Matches tags whose trimmed direct text matches pattern.
+// Tags without direct text never match
+func TextMatch(pattern *regexp.Regexp) Predicate {
+	return func(tag *Tag) bool {
+		text := strings.TrimSpace(tag.Text())
+		return text != "" && pattern.MatchString(text)
+	}
+}
+
+// Matches tags whose text, including inner tags, matches pattern.
+// Tags without text never match
+func FullTextMatch(pattern *regexp.Regexp) Predicate {
+	return func(tag *Tag) bool {
+		text := tag.FullText()
+		return text != "" && pattern.MatchString(text)
+	}
+}
+
+var numericPattern = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`)
+
+// Matches tags whose direct text is a number: optional sign, digits and
+// optional decimal point (e.g. "12", "-3.5", ".5"). Surrounding whitespace
+// and currency symbols (e.g. "$9", "10 €") are ignored. Exponents,
+// thousands separators, NaN and Inf are not considered numeric
+func TextIsNumeric() Predicate {
+	return func(tag *Tag) bool {
+		text := strings.TrimFunc(tag.Text(), func(r rune) bool {
+			return unicode.IsSpace(r) || unicode.Is(unicode.Sc, r)
+		})
+		return numericPattern.MatchString(text)
+	}
+}
+
+// Shorthand for matching tag name with all given classes,
+// e.g. Element("div", "card", "featured") for div.card.featured.
+// Empty name matches any tag
+func Element(name string, classes ...string) Predicate {
+	if name == "" {
+		return HasClassAll(classes...)
+	}
+	return All(HasName(name), HasClassAll(classes...))
+}
+
+// Matches tags at given 1-based position among their sibling tags.
+// Position is computed when predicate is evaluated
+func NthChild(n int) Predicate {
+	return func(tag *Tag) bool {
+		if tag.node.Parent == nil {
+			return false
+		}
+		return tag.Index()+1 == n
+	}
+}
+
+// Matches tags at given 1-based position among their sibling tags
+// with the same name. Position is computed when predicate is evaluated
+func NthChildOfType(n int) Predicate {
+	return func(tag *Tag) bool {
+		if tag.node.Parent == nil {
+			return false
+		}
+		position := 1
+		for prev := tag.Prev(); prev != nil; prev = prev.Prev() {
+			if prev.Name == tag.Name {
+				position++
+			}
+		}
+		return position == n
+	}
+}
+
+// Matches tags that are the first tag among their siblings.
+// Detached tags never match
+func IsFirstChild() Predicate {
+	return func(tag *Tag) bool {
+		return tag.node.Parent != nil && tag.Prev() == nil
+	}
+}
+
+// Matches tags that are the last tag among their siblings.
+// Detached tags never match
+func IsLastChild() Predicate {
+	return func(tag *Tag) bool {
+		return tag.node.Parent != nil && tag.Next() == nil
+	}
+}
+
+// Matches tags with no element children and at least one text child
+// that is not whitespace only, so empty tags never match.
+// Comments are ignored
+func ContainsOnlyText() Predicate {
+	return func(tag *Tag) bool {
+		hasText := false
+		for child := tag.node.FirstChild; child != nil; child = child.NextSibling {
+			switch child.Type {
+			case html.ElementNode:
+				return false
+			case html.TextNode:
+				if strings.TrimSpace(child.Data) != "" {
+					hasText = true
+				}
+			}
+		}
+		return hasText
+	}
+}
+
+// Matches void elements, which cannot have children, like <br> or <img>
+func IsVoidElement() Predicate {
+	return func(tag *Tag) bool {
+		return isVoidElement(tag.Name)
+	}
+}
+
+// Matches tags with any descendant matching predicate.
+// Search stops at the first matching descendant
+func HasDescendant(predicate Predicate) Predicate {
+	return func(tag *Tag) bool {
+		return tag.Find(predicate) != nil
+	}
+}
+
+// Matches tags whose parent tag matches predicate.
+// Root tag never matches
+func HasParent(predicate Predicate) Predicate {
+	return func(tag *Tag) bool {
+		parent := tag.Parent()
+		return parent != nil && predicate(parent)
+	}
+}
+
+// Matches tags with any ancestor tag matching predicate.
+// Root tag never matches
+func HasAncestor(predicate Predicate) Predicate {
+	return func(tag *Tag) bool {
+		return tag.FindParent(predicate) != nil
+	}
+}
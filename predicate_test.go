@@ -12,6 +12,28 @@ func TestHasName(t *testing.T) {
     }
 }
 
+func TestHasNameFold(t *testing.T) {
+    doc, err := ParseString(`<svg><clipPath id="clip"></clipPath></svg>`)
+    if err != nil {
+        t.Fatalf("Parse error: %v", err)
+    }
+
+    root := doc.Root()
+    if root.Find(HasName("clippath")) != nil {
+        t.Fatalf("expected HasName to miss mixed-case SVG tag")
+    }
+    clip := root.Find(HasNameFold("clippath"))
+    if clip == nil || clip.Attrs["id"] != "clip" {
+        t.Fatalf("HasNameFold failed")
+    }
+    if !HasNameFold("DIV")(&Tag{Name: "div"}) {
+        t.Fatalf("HasNameFold failed on upper case")
+    }
+    if HasNameFold("span")(&Tag{Name: "div"}) {
+        t.Fatalf("HasNameFold failed: false positive")
+    }
+}
+
 func TestHasAttr(t *testing.T) {
     tag := &Tag{Attrs: map[string]string{"id": "root"}}
     if !HasAttr("id")(tag) {
@@ -26,6 +48,21 @@ func TestHasNoAttr(t *testing.T) {
     }
 }
 
+func TestHasAnyName(t *testing.T) {
+    doc, err := ParseString(`<h1>A</h1><p>x</p><h3>B</h3><div><h6>C</h6></div>`)
+    if err != nil {
+        t.Fatalf("Parse error: %v", err)
+    }
+
+    headings := doc.Root().FindAll(HasAnyName("h1", "h2", "h3", "h4", "h5", "h6"))
+    if len(headings) != 3 || headings[0].Text() != "A" || headings[1].Text() != "B" || headings[2].Text() != "C" {
+        t.Fatalf("HasAnyName failed: got %v", headings)
+    }
+    if HasAnyName()(&Tag{Name: "div"}) {
+        t.Fatalf("HasAnyName failed: matched without names")
+    }
+}
+
 func TestHasClass(t *testing.T) {
     tag := &Tag{Attrs: map[string]string{"class": "ab foo1"}}
     if !HasClass("ab")(tag) {
@@ -36,6 +73,50 @@ func TestHasClass(t *testing.T) {
     }
 }
 
+func TestHasClassFold(t *testing.T) {
+    tag := &Tag{Attrs: map[string]string{"class": "nav active"}}
+    if !HasClassFold("Active")(tag) {
+        t.Fatalf("HasClassFold failed")
+    }
+    if HasClassFold("act")(tag) {
+        t.Fatalf("HasClassFold failed: false positive")
+    }
+    if HasClass("Active")(tag) {
+        t.Fatalf("HasClass failed: expected case-sensitive match")
+    }
+
+    tabbed := &Tag{Attrs: map[string]string{"class": "nav\tactive"}}
+    if HasClassFold("Active")(tabbed) != HasClass("active")(tabbed) {
+        t.Fatalf("HasClassFold failed: expected same splitting as HasClass")
+    }
+}
+
+func TestHasClassAll(t *testing.T) {
+    tag := &Tag{Attrs: map[string]string{"class": "btn btn-primary disabled"}}
+    if !HasClassAll("btn", "btn-primary")(tag) {
+        t.Fatalf("HasClassAll failed")
+    }
+    if HasClassAll("btn", "btn-secondary")(tag) {
+        t.Fatalf("HasClassAll failed: false positive")
+    }
+    if HasClassAll("btn")(&Tag{Attrs: map[string]string{}}) {
+        t.Fatalf("HasClassAll failed: false positive on missing class")
+    }
+}
+
+func TestHasClassAny(t *testing.T) {
+    tag := &Tag{Attrs: map[string]string{"class": "alert warning"}}
+    if !HasClassAny("error", "warning")(tag) {
+        t.Fatalf("HasClassAny failed")
+    }
+    if HasClassAny("error", "info")(tag) {
+        t.Fatalf("HasClassAny failed: false positive")
+    }
+    if HasClassAny()(tag) {
+        t.Fatalf("HasClassAny failed: matched with no classes given")
+    }
+}
+
 func TestHasNoClass(t *testing.T) {
     tagNoClass := &Tag{Attrs: map[string]string{}}
     tagWithClass := &Tag{Attrs: map[string]string{"class": "a"}}
@@ -47,6 +128,22 @@ func TestHasNoClass(t *testing.T) {
     }
 }
 
+func TestHasID(t *testing.T) {
+    tagNoID := &Tag{Attrs: map[string]string{}}
+    if HasID("root")(tagNoID) {
+        t.Fatalf("HasID failed: false positive on missing id")
+    }
+
+    doc, err := ParseString(sampleHTML)
+    if err != nil {
+        t.Fatalf("Parse error: %v", err)
+    }
+    div := doc.Root().Find(HasID("root"))
+    if div == nil || div.Name != "div" {
+        t.Fatalf("HasID failed on div#root")
+    }
+}
+
 func TestAttrEq(t *testing.T) {
     tag := &Tag{Attrs: map[string]string{"class": "a b", "id": "root"}}
     if !AttrEq("id", "root")(tag) {
@@ -54,6 +151,21 @@ func TestAttrEq(t *testing.T) {
     }
 }
 
+func TestAttrEqFold(t *testing.T) {
+    for _, value := range []string{"submit", "Submit", "SUBMIT"} {
+        tag := &Tag{Name: "button", Attrs: map[string]string{"type": value}}
+        if !AttrEqFold("type", "submit")(tag) {
+            t.Fatalf("AttrEqFold failed on %q", value)
+        }
+        if AttrEq("type", "submit")(tag) != (value == "submit") {
+            t.Fatalf("AttrEq behavior changed on %q", value)
+        }
+    }
+    if AttrEqFold("type", "submit")(&Tag{Attrs: map[string]string{}}) {
+        t.Fatalf("AttrEqFold failed: false positive on missing attribute")
+    }
+}
+
 func TestAttrContains(t *testing.T) {
     tag := &Tag{Attrs: map[string]string{"class": "ab b", "id": "root"}}
     if !AttrContains("class", "a")(tag) {
@@ -61,6 +173,38 @@ func TestAttrContains(t *testing.T) {
     }
 }
 
+func TestAttrStartsWith(t *testing.T) {
+    tag := &Tag{Attrs: map[string]string{"href": "/product/42"}}
+    if !AttrStartsWith("href", "/product/")(tag) {
+        t.Fatalf("AttrStartsWith failed")
+    }
+    if AttrStartsWith("href", "/42")(tag) {
+        t.Fatalf("AttrStartsWith failed: false positive")
+    }
+    if !AttrStartsWith("href", "")(tag) {
+        t.Fatalf("AttrStartsWith failed on empty prefix")
+    }
+    if AttrStartsWith("src", "")(tag) {
+        t.Fatalf("AttrStartsWith failed: false positive on missing attribute")
+    }
+}
+
+func TestAttrEndsWith(t *testing.T) {
+    tag := &Tag{Attrs: map[string]string{"src": "/img/photo.webp"}}
+    if !AttrEndsWith("src", ".webp")(tag) {
+        t.Fatalf("AttrEndsWith failed")
+    }
+    if AttrEndsWith("src", "/img")(tag) {
+        t.Fatalf("AttrEndsWith failed: false positive")
+    }
+    if !AttrEndsWith("src", "")(tag) {
+        t.Fatalf("AttrEndsWith failed on empty suffix")
+    }
+    if AttrEndsWith("href", "")(tag) {
+        t.Fatalf("AttrEndsWith failed: false positive on missing attribute")
+    }
+}
+
 func TestAttrMatch(t *testing.T) {
     tag := &Tag{Attrs: map[string]string{"class": "foo123", "id": "root"}}
     re := regexp.MustCompile(`foo\d+`)
@@ -69,6 +213,17 @@ func TestAttrMatch(t *testing.T) {
     }
 }
 
+func TestAttrKeyMatch(t *testing.T) {
+    tag := &Tag{Attrs: map[string]string{"class": "card", "data-v-7ba5bd90": ""}}
+    re := regexp.MustCompile(`^data-v-.*`)
+    if !AttrKeyMatch(re)(tag) {
+        t.Fatalf("AttrKeyMatch failed")
+    }
+    if AttrKeyMatch(re)(&Tag{Attrs: map[string]string{"data-id": "v-1"}}) {
+        t.Fatalf("AttrKeyMatch failed: false positive on value")
+    }
+}
+
 func TestAll(t *testing.T) {
     tag := &Tag{Name: "div", Attrs: map[string]string{"id": "root"}}
     if !All(HasName("div"), HasAttr("id"))(tag) {
@@ -81,4 +236,322 @@ func TestAny(t *testing.T) {
     if !Any(HasName("span"), HasAttr("id"))(tag) {
         t.Fatalf("Any failed")
     }
-}
\ No newline at end of file
+}
+
+func TestNot(t *testing.T) {
+    tag := &Tag{Name: "div", Attrs: map[string]string{"class": "active"}}
+    if Not(HasClass("active"))(tag) {
+        t.Fatalf("Not failed")
+    }
+    if !Not(Not(HasClass("active")))(tag) {
+        t.Fatalf("Not failed on double negation")
+    }
+    if !All(HasName("div"), Not(HasClass("hidden")))(tag) {
+        t.Fatalf("Not failed in All")
+    }
+    if Any(HasName("span"), Not(HasName("div")))(tag) {
+        t.Fatalf("Not failed in Any")
+    }
+    if Not(HasName("div"))(nil) {
+        t.Fatalf("Not failed: matched nil tag")
+    }
+}
+
+func TestTextEq(t *testing.T) {
+    doc, err := ParseString(sampleHTML)
+    if err != nil {
+        t.Fatalf("Parse error: %v", err)
+    }
+
+    root := doc.Root()
+    p := root.Find(TextEq("Second"))
+    if p == nil || !HasClass("b")(p) {
+        t.Fatalf("TextEq failed")
+    }
+    if root.Find(All(HasName("p"), TextEq("Hello"))) == nil {
+        t.Fatalf("TextEq failed on untrimmed text")
+    }
+    if root.Find(TextEq("Sec")) != nil {
+        t.Fatalf("TextEq failed: false positive on partial text")
+    }
+}
+
+func TestTextContains(t *testing.T) {
+    doc, err := ParseString(sampleHTML)
+    if err != nil {
+        t.Fatalf("Parse error: %v", err)
+    }
+
+    root := doc.Root()
+    p := root.Find(TextContains("ont"))
+    if p == nil || p.Text() != "Content" {
+        t.Fatalf("TextContains failed")
+    }
+    if root.Find(All(HasName("p"), TextContains("World"))) != nil {
+        t.Fatalf("TextContains failed: false positive on inner tag text")
+    }
+}
+
+func TestFullTextContains(t *testing.T) {
+    doc, err := ParseString(sampleHTML)
+    if err != nil {
+        t.Fatalf("Parse error: %v", err)
+    }
+
+    root := doc.Root()
+    p := root.Find(All(HasName("p"), FullTextContains("Hello World")))
+    if p == nil || !HasClass("a")(p) {
+        t.Fatalf("FullTextContains failed")
+    }
+}
+
+func TestTextMatch(t *testing.T) {
+    doc, err := ParseString(`<div><span>$12.99</span><span>12.99</span><i></i></div>`)
+    if err != nil {
+        t.Fatalf("Parse error: %v", err)
+    }
+
+    root := doc.Root()
+    re := regexp.MustCompile(`^\$\d+\.\d{2}$`)
+    found := root.FindAll(TextMatch(re))
+    if len(found) != 1 || found[0].Text() != "$12.99" {
+        t.Fatalf("TextMatch failed")
+    }
+    if TextMatch(regexp.MustCompile(`.*`))(root.Find(HasName("i"))) {
+        t.Fatalf("TextMatch failed: matched tag without text")
+    }
+}
+
+func TestFullTextMatch(t *testing.T) {
+    doc, err := ParseString(`<div><p>Total: <b>$5.00</b></p><p></p></div>`)
+    if err != nil {
+        t.Fatalf("Parse error: %v", err)
+    }
+
+    root := doc.Root()
+    re := regexp.MustCompile(`Total: \$\d+\.\d{2}`)
+    found := root.FindAll(All(HasName("p"), FullTextMatch(re)))
+    if len(found) != 1 {
+        t.Fatalf("FullTextMatch failed: expected 1 match, got %d", len(found))
+    }
+    if FullTextMatch(regexp.MustCompile(`.*`))(root.FindAll(HasName("p"))[1]) {
+        t.Fatalf("FullTextMatch failed: matched tag without text")
+    }
+}
+
+func TestTextIsNumeric(t *testing.T) {
+    doc, err := ParseString(`<table><tr><td>12.50</td><td>$9</td><td>abc</td><td> 10 € </td><td>1e5</td></tr></table>`)
+    if err != nil {
+        t.Fatalf("Parse error: %v", err)
+    }
+
+    cells := doc.Root().FindAll(HasName("td"))
+    expected := []bool{true, true, false, true, false}
+    for i, cell := range cells {
+        if TextIsNumeric()(cell) != expected[i] {
+            t.Fatalf("TextIsNumeric failed on %q: expected %v", cell.Text(), expected[i])
+        }
+    }
+}
+
+func TestElement(t *testing.T) {
+    tag := &Tag{Name: "div", Attrs: map[string]string{"class": "card featured"}}
+    if !Element("div", "card", "featured")(tag) {
+        t.Fatalf("Element failed")
+    }
+    if !Element("", "featured")(tag) {
+        t.Fatalf("Element failed with empty name")
+    }
+    if Element("div", "card", "hidden")(tag) {
+        t.Fatalf("Element failed: false positive on missing class")
+    }
+    if Element("span", "card")(tag) {
+        t.Fatalf("Element failed: false positive on name")
+    }
+}
+
+func TestNthChild(t *testing.T) {
+    doc, err := ParseString(sampleHTML)
+    if err != nil {
+        t.Fatalf("Parse error: %v", err)
+    }
+
+    root := doc.Root()
+    found := root.FindAll(NthChild(2))
+    expected := []string{"body", "p", "p"}
+    if len(found) != len(expected) {
+        t.Fatalf("NthChild failed: expected %d tags, got %d", len(expected), len(found))
+    }
+    for i, tag := range found {
+        if tag.Name != expected[i] {
+            t.Fatalf("NthChild failed: expected %q at index %d, got %q", expected[i], i, tag.Name)
+        }
+    }
+
+    detached := NewTag("p", nil)
+    if NthChild(0)(detached) || NthChild(1)(detached) {
+        t.Fatalf("NthChild failed: expected no match for detached tag")
+    }
+}
+
+func TestNthChildOfType(t *testing.T) {
+    doc, err := ParseString(sampleHTML)
+    if err != nil {
+        t.Fatalf("Parse error: %v", err)
+    }
+
+    root := doc.Root()
+    found := root.FindAll(NthChildOfType(2))
+    if len(found) != 1 || found[0].Text() != "Second" {
+        t.Fatalf("NthChildOfType failed")
+    }
+    if len(root.FindAll(All(HasName("article"), NthChildOfType(1)))) != 1 {
+        t.Fatalf("NthChildOfType failed on first of type")
+    }
+}
+
+func TestIsFirstChild(t *testing.T) {
+    doc, err := ParseString(sampleHTML)
+    if err != nil {
+        t.Fatalf("Parse error: %v", err)
+    }
+
+    children := doc.Root().Find(HasID("root")).Children()
+    for i, child := range children {
+        if IsFirstChild()(child) != (i == 0) {
+            t.Fatalf("IsFirstChild failed at index %d", i)
+        }
+    }
+
+    children[0].Remove()
+    if IsFirstChild()(children[0]) {
+        t.Fatalf("IsFirstChild failed: matched detached tag")
+    }
+}
+
+func TestIsLastChild(t *testing.T) {
+    doc, err := ParseString(sampleHTML)
+    if err != nil {
+        t.Fatalf("Parse error: %v", err)
+    }
+
+    children := doc.Root().Find(HasID("root")).Children()
+    for i, child := range children {
+        if IsLastChild()(child) != (i == len(children)-1) {
+            t.Fatalf("IsLastChild failed at index %d", i)
+        }
+    }
+
+    last := children[len(children)-1]
+    last.Remove()
+    if IsLastChild()(last) {
+        t.Fatalf("IsLastChild failed: matched detached tag")
+    }
+}
+
+func TestContainsOnlyText(t *testing.T) {
+    doc, err := ParseString(`<p>Hello <span>World</span></p><div><span/></div><em> </em>`)
+    if err != nil {
+        t.Fatalf("Parse error: %v", err)
+    }
+
+    root := doc.Root()
+    if !ContainsOnlyText()(root.Find(HasName("span"))) {
+        t.Fatalf("ContainsOnlyText failed")
+    }
+    if ContainsOnlyText()(root.Find(HasName("p"))) {
+        t.Fatalf("ContainsOnlyText failed: false positive on mixed content")
+    }
+    if ContainsOnlyText()(root.Find(HasName("div"))) {
+        t.Fatalf("ContainsOnlyText failed: false positive on element child")
+    }
+    if ContainsOnlyText()(root.Find(HasName("em"))) {
+        t.Fatalf("ContainsOnlyText failed: false positive on whitespace only")
+    }
+}
+
+func TestIsVoidElement(t *testing.T) {
+    doc, err := ParseString(`<p>Text<br><img src="a.png"><input name="q"><span></span></p>`)
+    if err != nil {
+        t.Fatalf("Parse error: %v", err)
+    }
+
+    root := doc.Root()
+    for _, name := range []string{"br", "img", "input"} {
+        if !IsVoidElement()(root.Find(HasName(name))) {
+            t.Fatalf("IsVoidElement failed on %s", name)
+        }
+    }
+    for _, name := range []string{"p", "span", "body"} {
+        if IsVoidElement()(root.Find(HasName(name))) {
+            t.Fatalf("IsVoidElement failed: false positive on %s", name)
+        }
+    }
+}
+
+func TestHasDescendant(t *testing.T) {
+    doc, err := ParseString(sampleHTML)
+    if err != nil {
+        t.Fatalf("Parse error: %v", err)
+    }
+
+    root := doc.Root()
+    if !HasDescendant(HasName("h1"))(root.Find(HasName("article"))) {
+        t.Fatalf("HasDescendant failed")
+    }
+    if HasDescendant(HasName("article"))(root.Find(HasName("article"))) {
+        t.Fatalf("HasDescendant failed: matched tag itself")
+    }
+
+    found := root.FindAll(All(HasName("p"), HasDescendant(HasName("span"))))
+    if len(found) != 1 || found[0].Attrs["class"] != "a b" {
+        t.Fatalf("HasDescendant failed: got %v", found)
+    }
+
+    checked := 0
+    HasDescendant(func(tag *Tag) bool {
+        checked++
+        return tag.Name == "p"
+    })(root.Find(HasID("root")))
+    if checked != 1 {
+        t.Fatalf("HasDescendant failed: expected to stop at first match, checked %d", checked)
+    }
+}
+
+func TestHasParent(t *testing.T) {
+    doc, err := ParseString(`<ol><li>One</li></ol><ul><li>Two</li></ul>`)
+    if err != nil {
+        t.Fatalf("Parse error: %v", err)
+    }
+
+    root := doc.Root()
+    found := root.FindAll(All(HasName("li"), HasParent(HasName("ol"))))
+    if len(found) != 1 || found[0].Text() != "One" {
+        t.Fatalf("HasParent failed: got %v", found)
+    }
+    if HasParent(HasName("body"))(root.Find(HasName("li"))) {
+        t.Fatalf("HasParent failed: matched grandparent")
+    }
+    if HasParent(func(*Tag) bool { return true })(root) {
+        t.Fatalf("HasParent failed: matched root")
+    }
+}
+
+func TestHasAncestor(t *testing.T) {
+    doc, err := ParseString(sampleHTML)
+    if err != nil {
+        t.Fatalf("Parse error: %v", err)
+    }
+
+    root := doc.Root()
+    found := root.FindAll(All(HasName("p"), HasAncestor(HasName("article"))))
+    if len(found) != 1 || found[0].Text() != "Content" {
+        t.Fatalf("HasAncestor failed: got %v", found)
+    }
+    if len(root.FindAll(All(HasName("p"), HasAncestor(HasID("root"))))) != 3 {
+        t.Fatalf("HasAncestor failed: expected all paragraphs under root")
+    }
+    if HasAncestor(func(*Tag) bool { return true })(root) {
+        t.Fatalf("HasAncestor failed: matched root")
+    }
+}
@@ -0,0 +1,283 @@
+package gosoup
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Select all children tags matching CSS selector.
+// Supported syntax: tag names, *, .class, #id, [attr], [attr=value]
+// and descendant (space) and child (>) combinators.
+// Returns empty slice if nothing matches or selector is malformed,
+// use SelectErr to tell these cases apart
+func (tag *Tag) Select(selector string) []*Tag {
+	result, _ := tag.SelectErr(selector)
+	return result
+}
+
+// Select all children tags matching CSS selector,
+// returning an error if selector is malformed
+func (tag *Tag) SelectErr(selector string) ([]*Tag, error) {
+	predicate, err := compileSelector(selector)
+	if err != nil {
+		return []*Tag{}, err
+	}
+
+	result := tag.FindAll(predicate)
+	if result == nil {
+		result = []*Tag{}
+	}
+
+	return result, nil
+}
+
+// Select first child tag matching CSS selector.
+// Returns nil if nothing matches or selector is malformed
+func (tag *Tag) SelectFirst(selector string) *Tag {
+	predicate, err := compileSelector(selector)
+	if err != nil {
+		return nil
+	}
+	return tag.Find(predicate)
+}
+
+// Compound selector with combinator linking it to the previous one
+type selectorPart struct {
+	combinator byte
+	predicate  Predicate
+}
+
+// Compile CSS selector into predicate
+func compileSelector(selector string) (Predicate, error) {
+	p := &selectorParser{input: selector}
+
+	parts, err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %q: %w", selector, err)
+	}
+
+	return func(tag *Tag) bool {
+		return matchSelector(parts, len(parts)-1, tag)
+	}, nil
+}
+
+// Match selector parts right to left, starting from given tag
+func matchSelector(parts []selectorPart, i int, tag *Tag) bool {
+	if !parts[i].predicate(tag) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+
+	if parts[i].combinator == '>' {
+		parent := tag.Parent()
+		return parent != nil && matchSelector(parts, i-1, parent)
+	}
+
+	for parent := tag.Parent(); parent != nil; parent = parent.Parent() {
+		if matchSelector(parts, i-1, parent) {
+			return true
+		}
+	}
+	return false
+}
+
+type selectorParser struct {
+	input string
+	pos   int
+}
+
+func (p *selectorParser) parse() ([]selectorPart, error) {
+	var parts []selectorPart
+
+	p.skipSpace()
+	if p.pos >= len(p.input) {
+		return nil, fmt.Errorf("empty selector")
+	}
+
+	combinator := byte(0)
+	for {
+		predicate, err := p.parseCompound()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, selectorPart{combinator: combinator, predicate: predicate})
+
+		hasSpace := p.skipSpace()
+		if p.pos >= len(p.input) {
+			return parts, nil
+		}
+
+		switch {
+		case p.input[p.pos] == '>':
+			p.pos++
+			p.skipSpace()
+			combinator = '>'
+		case hasSpace:
+			combinator = ' '
+		default:
+			return nil, p.unexpected()
+		}
+
+		if p.pos >= len(p.input) {
+			return nil, fmt.Errorf("missing selector after combinator")
+		}
+	}
+}
+
+// Parse sequence of simple selectors not separated by combinators
+func (p *selectorParser) parseCompound() (Predicate, error) {
+	var predicates []Predicate
+
+	if p.pos < len(p.input) && p.input[p.pos] == '*' {
+		p.pos++
+		predicates = append(predicates, func(*Tag) bool { return true })
+	} else if name := p.parseIdent(); name != "" {
+		predicates = append(predicates, HasName(strings.ToLower(name)))
+	}
+
+	for p.pos < len(p.input) {
+		switch p.input[p.pos] {
+		case '.':
+			p.pos++
+			class := p.parseIdent()
+			if class == "" {
+				return nil, p.unexpected()
+			}
+			predicates = append(predicates, hasClassToken(class))
+		case '#':
+			p.pos++
+			id := p.parseIdent()
+			if id == "" {
+				return nil, p.unexpected()
+			}
+			predicates = append(predicates, HasID(id))
+		case '[':
+			p.pos++
+			predicate, err := p.parseAttr()
+			if err != nil {
+				return nil, err
+			}
+			predicates = append(predicates, predicate)
+		default:
+			if len(predicates) == 0 {
+				return nil, p.unexpected()
+			}
+			return All(predicates...), nil
+		}
+	}
+
+	if len(predicates) == 0 {
+		return nil, p.unexpected()
+	}
+	return All(predicates...), nil
+}
+
+// Parse attribute selector after opening bracket
+func (p *selectorParser) parseAttr() (Predicate, error) {
+	p.skipSpace()
+	attr := strings.ToLower(p.parseIdent())
+	if attr == "" {
+		return nil, p.unexpected()
+	}
+	p.skipSpace()
+
+	if p.pos >= len(p.input) {
+		return nil, fmt.Errorf("unterminated attribute selector")
+	}
+
+	if p.input[p.pos] == ']' {
+		p.pos++
+		return HasAttr(attr), nil
+	}
+
+	if p.input[p.pos] != '=' {
+		return nil, p.unexpected()
+	}
+	p.pos++
+	p.skipSpace()
+
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+
+	if p.pos >= len(p.input) {
+		return nil, fmt.Errorf("unterminated attribute selector")
+	}
+	if p.input[p.pos] != ']' {
+		return nil, p.unexpected()
+	}
+	p.pos++
+
+	return AttrEq(attr, value), nil
+}
+
+// Parse quoted string or identifier as attribute value
+func (p *selectorParser) parseValue() (string, error) {
+	if p.pos >= len(p.input) {
+		return "", fmt.Errorf("unterminated attribute selector")
+	}
+
+	quote := p.input[p.pos]
+	if quote != '"' && quote != '\'' {
+		value := p.parseIdent()
+		if value == "" {
+			return "", p.unexpected()
+		}
+		return value, nil
+	}
+
+	end := strings.IndexByte(p.input[p.pos+1:], quote)
+	if end < 0 {
+		return "", fmt.Errorf("unterminated string at offset %d", p.pos)
+	}
+
+	value := p.input[p.pos+1 : p.pos+1+end]
+	p.pos += end + 2
+
+	return value, nil
+}
+
+func (p *selectorParser) parseIdent() string {
+	start := p.pos
+	for p.pos < len(p.input) && isIdentChar(p.input[p.pos]) {
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+// Skip whitespace, reporting whether any was skipped
+func (p *selectorParser) skipSpace() bool {
+	start := p.pos
+	for p.pos < len(p.input) && isASCIISpace(p.input[p.pos]) {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+func (p *selectorParser) unexpected() error {
+	if p.pos >= len(p.input) {
+		return fmt.Errorf("unexpected end of selector")
+	}
+	return fmt.Errorf("unexpected %q at offset %d", p.input[p.pos], p.pos)
+}
+
+// Check whether byte can be a part of CSS identifier
+// Match tags having given class, splitting class attribute
+// on any ASCII whitespace as CSS class selectors do
+func hasClassToken(class string) Predicate {
+	return func(tag *Tag) bool {
+		return slices.Contains(tag.ClassList(), class)
+	}
+}
+
+func isIdentChar(c byte) bool {
+	return c >= 'a' && c <= 'z' ||
+		c >= 'A' && c <= 'Z' ||
+		c >= '0' && c <= '9' ||
+		c == '-' || c == '_' || c >= 0x80
+}
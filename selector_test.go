@@ -0,0 +1,130 @@
+package gosoup
+
+import (
+	"testing"
+)
+
+func TestSelect(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	tests := []struct {
+		selector string
+		expected []string
+	}{
+		{"p", []string{"p", "p", "p"}},
+		{"span", []string{"span"}},
+		{".b", []string{"p", "p"}},
+		{"p.a.b", []string{"p"}},
+		{"#root", []string{"div"}},
+		{"div#root.container", []string{"div"}},
+		{"[class]", []string{"div", "p", "p"}},
+		{`[class="a b"]`, []string{"p"}},
+		{"[id=root]", []string{"div"}},
+		{"div p", []string{"p", "p", "p"}},
+		{"div > p", []string{"p", "p"}},
+		{"div.container>p.a span", []string{"span"}},
+		{"article > *", []string{"h1", "p"}},
+		{"body > p", []string{}},
+		{"video", []string{}},
+	}
+
+	for _, test := range tests {
+		found := root.Select(test.selector)
+		if found == nil {
+			t.Fatalf("Select(%q) returned nil slice", test.selector)
+		}
+		if len(found) != len(test.expected) {
+			t.Fatalf("Select(%q): expected %d tags, got %d", test.selector, len(test.expected), len(found))
+		}
+		for i, tag := range found {
+			if tag.Name != test.expected[i] {
+				t.Fatalf("Select(%q): expected %q at index %d, got %q", test.selector, test.expected[i], i, tag.Name)
+			}
+		}
+	}
+}
+
+func TestSelectDocumentOrder(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	found := root.Select("div p")
+	expected := root.FindAll(HasName("p"))
+	for i := range expected {
+		if found[i] != expected[i] {
+			t.Fatalf("expected Select to follow document order at index %d", i)
+		}
+	}
+}
+
+func TestSelectClassWhitespace(t *testing.T) {
+	doc, err := ParseString("<p class=\"a\tb\">tab</p><p class=\"c\nb\">newline</p><p class=\"ab\">none</p>")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	found := doc.Root().Select("p.b")
+	if len(found) != 2 || found[0].Text() != "tab" || found[1].Text() != "newline" {
+		t.Fatalf("expected classes split on any whitespace, got %v", found)
+	}
+}
+
+func TestSelectFirst(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	found := root.SelectFirst("article p")
+	if found == nil {
+		t.Fatalf("SelectFirst() returned nil")
+	}
+	if found.Text() != "Content" {
+		t.Fatalf("expected 'Content', got %q", found.Text())
+	}
+
+	if found := root.SelectFirst("article > span"); found != nil {
+		t.Fatalf("expected nil for no match, got %v", found)
+	}
+	if found := root.SelectFirst("p >"); found != nil {
+		t.Fatalf("expected nil for malformed selector, got %v", found)
+	}
+}
+
+func TestSelectErr(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	found, err := root.SelectErr("video")
+	if err != nil {
+		t.Fatalf("unexpected error for valid selector: %v", err)
+	}
+	if found == nil || len(found) != 0 {
+		t.Fatalf("expected empty slice, got %v", found)
+	}
+
+	for _, selector := range []string{"", "   ", "p >", "> p", "p..a", "#", "[class", "[=a]", `[class="a]`, "p, span", "div ~ p"} {
+		found, err := root.SelectErr(selector)
+		if err == nil {
+			t.Fatalf("expected error for malformed selector %q", selector)
+		}
+		if found == nil || len(found) != 0 {
+			t.Fatalf("expected empty slice for malformed selector %q, got %v", selector, found)
+		}
+	}
+}
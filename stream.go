@@ -0,0 +1,68 @@
+package gosoup
+
+import (
+	"io"
+
+	"golang.org/x/net/html"
+)
+
+// Closing tag reported by ParseStream
+type EndTag struct {
+	Name string
+}
+
+func (et EndTag) isNode() {}
+
+// Render closing tag
+func (et EndTag) String() string {
+	return "</" + et.Name + ">"
+}
+
+// Parse HTML from a reader token by token, calling handler for every
+// start tag (*Tag), text (NavigableString) and end tag (EndTag) in source
+// order. Comments and doctype are skipped.
+// No tree is built, so memory use does not grow with document size:
+// reported tags are detached and have neither parent nor children,
+// so tree navigation and search methods find nothing on them.
+// End tags are never synthesized: only closing tags present in the source
+// are reported, so void elements like <br>, self-closing tags like <img/>
+// and tags closed implicitly have no EndTag.
+// Parsing stops at the first error returned by handler
+func ParseStream(reader io.Reader, handler func(Node) error) error {
+	tokenizer := html.NewTokenizer(reader)
+
+	for {
+		var node Node
+
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				return err
+			}
+			return nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			node = streamTag(tokenizer.Token())
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			node = EndTag{Name: string(name)}
+		case html.TextToken:
+			node = NavigableString{Text: string(tokenizer.Text())}
+		default:
+			continue
+		}
+
+		if err := handler(node); err != nil {
+			return err
+		}
+	}
+}
+
+// Create detached tag from start tag token
+func streamTag(token html.Token) *Tag {
+	return detachedTag(&html.Node{
+		Type:     html.ElementNode,
+		DataAtom: token.DataAtom,
+		Data:     token.Data,
+		Attr:     token.Attr,
+	})
+}
@@ -0,0 +1,75 @@
+package gosoup
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseStream(t *testing.T) {
+	var events []string
+	err := ParseStream(strings.NewReader(`<!-- skipped --><div id="a">Hi<br><img src="x"/></div>`), func(node Node) error {
+		switch node := node.(type) {
+		case *Tag:
+			events = append(events, "start "+node.Name+" "+node.Attrs["id"]+node.Attrs["src"])
+		case EndTag:
+			events = append(events, "end "+node.Name)
+		case NavigableString:
+			events = append(events, "text "+node.Text)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ParseStream error: %v", err)
+	}
+
+	expected := []string{"start div a", "text Hi", "start br ", "start img x", "end div"}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %v", len(expected), events)
+	}
+	for i, event := range events {
+		if event != expected[i] {
+			t.Fatalf("expected %q at index %d, got %q", expected[i], i, event)
+		}
+	}
+}
+
+func TestParseStreamLarge(t *testing.T) {
+	const rows = 100000
+	input := "<table>" + strings.Repeat("<tr><td>cell</td></tr>", rows) + "</table>"
+
+	count := 0
+	err := ParseStream(strings.NewReader(input), func(node Node) error {
+		if tag, ok := node.(*Tag); ok && tag.Name == "td" {
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ParseStream error: %v", err)
+	}
+	if count != rows {
+		t.Fatalf("expected %d cells, got %d", rows, count)
+	}
+}
+
+func TestParseStreamHandlerError(t *testing.T) {
+	stop := errors.New("stop")
+
+	count := 0
+	err := ParseStream(strings.NewReader("<p>1</p><p>2</p><p>3</p>"), func(node Node) error {
+		if _, ok := node.(*Tag); ok {
+			count++
+			if count == 2 {
+				return stop
+			}
+		}
+		return nil
+	})
+	if err != stop {
+		t.Fatalf("expected handler error, got %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("expected parsing to stop after 2 tags, got %d", count)
+	}
+}
@@ -1,7 +1,12 @@
 package gosoup
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
 	"iter"
+	"slices"
 	"strings"
 
 	"golang.org/x/net/html"
@@ -9,6 +14,7 @@ import (
 
 // Corresponds to HTML node in the document (tag, raw string, etc.)
 type Node interface {
+	String() string
 	isNode()
 }
 
@@ -19,6 +25,11 @@ type NavigableString struct {
 
 func (ns NavigableString) isNode() {}
 
+// Get raw string as is
+func (ns NavigableString) String() string {
+	return ns.Text
+}
+
 // Corresponds to HTML tag in the document
 type Tag struct {
 	Name  string
@@ -29,13 +40,71 @@ type Tag struct {
 
 func (t *Tag) isNode() {}
 
+// Get underlying node of golang.org/x/net/html tree.
+// It should be treated as read-only: changes made to the node directly
+// bypass the tag cache, so Attrs and cached tags may get out of sync
+func (tag *Tag) Node() *html.Node {
+	return tag.node
+}
+
 // Render a tree with a current tag as root
 func (tag *Tag) String() string {
 	var builder strings.Builder
-	html.Render(&builder, tag.node)
+	tag.Render(&builder)
+	return builder.String()
+}
+
+// Render a tree with a current tag as root to given writer
+func (tag *Tag) Render(w io.Writer) error {
+	return html.Render(w, tag.node)
+}
+
+// Render children nodes of a current tag, excluding tag itself
+func (tag *Tag) InnerHTML() string {
+	var builder strings.Builder
+	for child := tag.node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.TextNode && isRawTextElement(tag.Name) {
+			builder.WriteString(child.Data)
+			continue
+		}
+		html.Render(&builder, child)
+	}
 	return builder.String()
 }
 
+// Remove all children nodes of a current tag, including text and comments
+func (tag *Tag) Empty() {
+	tag.doc.cacheMu.Lock()
+	defer tag.doc.cacheMu.Unlock()
+
+	for child := tag.node.FirstChild; child != nil; child = tag.node.FirstChild {
+		tag.node.RemoveChild(child)
+		delete(tag.doc.cache, child)
+	}
+}
+
+// Replace children of a current tag with nodes parsed from markup
+// in context of the tag. Malformed markup is recovered from the same
+// way browsers do, so error is returned only for void elements,
+// which cannot have children
+func (tag *Tag) SetInnerHTML(markup string) error {
+	if isVoidElement(tag.Name) {
+		return errors.New("void element cannot have children")
+	}
+
+	nodes, err := parseHTMLFragment(strings.NewReader(markup), tag.node)
+	if err != nil {
+		return err
+	}
+
+	tag.Empty()
+	for _, node := range nodes {
+		tag.node.AppendChild(node)
+	}
+
+	return nil
+}
+
 // Get a parent tag
 func (tag *Tag) Parent() *Tag {
 	for parent := tag.node.Parent; parent != nil; parent = parent.Parent {
@@ -56,7 +125,7 @@ func (tag *Tag) FirstChild() *Tag {
 	return nil
 }
 
-// Get all children tags recursively
+// Get all direct children tags
 func (tag *Tag) Children() []*Tag {
 	var children []*Tag
 
@@ -128,9 +197,10 @@ func (tag *Tag) Text() string {
 	return ""
 }
 
-// Get all human-readable text of a current tree
+// Get all human-readable text of a current tree,
+// placing separator between text fragments
 func (tag *Tag) FullText(sep ...string) string {
-	builder := &strings.Builder{}
+	var fragments []string
 
 	var traverse func(*html.Node)
 	traverse = func(node *html.Node) {
@@ -138,9 +208,31 @@ func (tag *Tag) FullText(sep ...string) string {
 			return
 		}
 		if node.Type == html.TextNode {
-			builder.WriteString(node.Data)
-			for _, s := range sep {
-				builder.WriteString(s)
+			fragments = append(fragments, node.Data)
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			traverse(child)
+		}
+	}
+
+	traverse(tag.node)
+
+	return strings.Join(fragments, strings.Join(sep, ""))
+}
+
+// Get text of a current tree as users see it, like FullText,
+// but skipping content of <script>, <style>, <noscript> and <template>
+func (tag *Tag) VisibleText(sep ...string) string {
+	var fragments []string
+
+	var traverse func(*html.Node)
+	traverse = func(node *html.Node) {
+		switch node.Type {
+		case html.TextNode:
+			fragments = append(fragments, node.Data)
+		case html.ElementNode:
+			if isHiddenElement(node.Data) {
+				return
 			}
 		}
 		for child := node.FirstChild; child != nil; child = child.NextSibling {
@@ -150,78 +242,511 @@ func (tag *Tag) FullText(sep ...string) string {
 
 	traverse(tag.node)
 
-	return builder.String()
+	return strings.Join(fragments, strings.Join(sep, ""))
 }
 
-// Removes current tag from a tree
-func (tag *Tag) Unwrap() {
-	tag.doc.removeTag(tag)
+// Check whether content of tag with given name is not shown to users
+func isHiddenElement(name string) bool {
+	switch name {
+	case "script", "style", "noscript", "template":
+		return true
+	}
+	return false
 }
 
-// Find chidl tag by predicate
-func (tag *Tag) Find(predicate Predicate) *Tag {
-	var find func(*Tag, bool) *Tag
+// Get all human-readable text of a current tree with runs of
+// ASCII whitespace collapsed to a single space and trimmed,
+// similar to how browsers render inline text
+func (tag *Tag) FullTextNormalized() string {
+	return strings.Trim(collapseSpace(tag.FullText()), " ")
+}
 
-	find = func(t *Tag, skipCheck bool) *Tag {
-		if !skipCheck && predicate(t) {
-			return t
+// Get text of a current tree laid out roughly as browsers render it:
+// block tags like <p> and <div> start new lines, <br> is a hard line
+// break and cells of a table row are separated by a space. Other inline
+// tags get no space around them, as in browsers, so "<b>go</b>pher"
+// reads "gopher". Whitespace within lines is collapsed,
+// except for line breaks inside <pre>, and blank lines are collapsed
+// to a single one. Content of <script>, <style>, <noscript> and
+// <template> tags is skipped
+func (tag *Tag) RenderedText() string {
+	var lines []string
+	var line strings.Builder
+
+	breakLine := func(hard bool) {
+		text := strings.Trim(collapseSpace(line.String()), " ")
+		line.Reset()
+		if text != "" || hard {
+			lines = append(lines, text)
 		}
+	}
 
-		for child := t.FirstChild(); child != nil; child = child.Next() {
-			if found := find(child, false); found != nil {
-				return found
+	var traverse func(*html.Node, bool)
+	traverse = func(node *html.Node, pre bool) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			switch child.Type {
+			case html.TextNode:
+				if !pre {
+					line.WriteString(child.Data)
+					continue
+				}
+				for i, part := range strings.Split(child.Data, "\n") {
+					if i > 0 {
+						breakLine(true)
+					}
+					line.WriteString(part)
+				}
+			case html.ElementNode:
+				switch {
+				case isHiddenElement(child.Data):
+				case child.Data == "br":
+					breakLine(true)
+				case isBlockElement(child.Data):
+					breakLine(false)
+					traverse(child, pre || child.Data == "pre")
+					breakLine(false)
+				case child.Data == "td" || child.Data == "th":
+					line.WriteString(" ")
+					traverse(child, pre)
+					line.WriteString(" ")
+				default:
+					traverse(child, pre)
+				}
 			}
 		}
+	}
+
+	traverse(tag.node, tag.Name == "pre")
+	breakLine(false)
+
+	var result []string
+	for _, text := range lines {
+		if text == "" && (len(result) == 0 || result[len(result)-1] == "") {
+			continue
+		}
+		result = append(result, text)
+	}
+
+	return strings.TrimRight(strings.Join(result, "\n"), "\n")
+}
+
+// Removes current tag with all its content from a tree.
+// Does nothing if tag is already detached
+func (tag *Tag) Remove() {
+	if tag.node.Parent == nil {
+		return
+	}
+	tag.doc.removeTag(tag)
+}
+
+// Replaces current tag with its children, keeping their position.
+// Unlike Remove, content of the tag stays in a tree
+func (tag *Tag) Unwrap() {
+	parent := tag.node.Parent
+	if parent == nil {
+		return
+	}
+
+	for child := tag.node.FirstChild; child != nil; child = tag.node.FirstChild {
+		tag.node.RemoveChild(child)
+		parent.InsertBefore(child, tag.node)
+	}
+
+	tag.doc.removeTag(tag)
+}
+
+// Insert child tag as the last child of current tag.
+// Child is detached from its previous place first
+func (tag *Tag) AppendChild(child *Tag) error {
+	if err := checkInsert(tag.node, child); err != nil {
+		return err
+	}
+	if isVoidElement(tag.Name) {
+		return errors.New("void element cannot have children")
+	}
+
+	tag.doc.adoptTag(child)
+	tag.node.AppendChild(child.node)
+
+	return nil
+}
+
+// Insert child tag as the first child of current tag.
+// Child is detached from its previous place first
+func (tag *Tag) PrependChild(child *Tag) error {
+	if err := checkInsert(tag.node, child); err != nil {
+		return err
+	}
+	if isVoidElement(tag.Name) {
+		return errors.New("void element cannot have children")
+	}
+
+	tag.doc.adoptTag(child)
+	tag.node.InsertBefore(child.node, tag.node.FirstChild)
+
+	return nil
+}
+
+// Insert sibling tag right before current tag.
+// Sibling is detached from its previous place first
+func (tag *Tag) InsertBefore(sibling *Tag) error {
+	if err := tag.checkSibling(sibling); err != nil {
+		return err
+	}
+
+	tag.doc.adoptTag(sibling)
+	tag.node.Parent.InsertBefore(sibling.node, tag.node)
+
+	return nil
+}
+
+// Insert sibling tag right after current tag.
+// Sibling is detached from its previous place first
+func (tag *Tag) InsertAfter(sibling *Tag) error {
+	if err := tag.checkSibling(sibling); err != nil {
+		return err
+	}
+
+	tag.doc.adoptTag(sibling)
+	tag.node.Parent.InsertBefore(sibling.node, tag.node.NextSibling)
+
+	return nil
+}
 
+// Replace current tag with replacement tag at the same position.
+// Replacement is detached from its previous place first
+func (tag *Tag) ReplaceWith(replacement *Tag) error {
+	if replacement != nil && replacement.node == tag.node {
 		return nil
 	}
+	if err := tag.checkSibling(replacement); err != nil {
+		return err
+	}
+
+	tag.doc.adoptTag(replacement)
+	tag.node.Parent.InsertBefore(replacement.node, tag.node)
+	tag.doc.removeTag(tag)
+
+	return nil
+}
+
+// Wrap current tag with wrapper tag, putting wrapper at the position
+// of current tag and moving current tag inside it as the last child.
+// Wrapper is detached from its previous place first
+func (tag *Tag) WrapWith(wrapper *Tag) error {
+	if err := tag.checkSibling(wrapper); err != nil {
+		return err
+	}
+	if err := checkInsert(wrapper.node, tag); err != nil {
+		return err
+	}
+	if isVoidElement(wrapper.Name) {
+		return errors.New("void element cannot have children")
+	}
+
+	tag.doc.adoptTag(wrapper)
+	tag.node.Parent.InsertBefore(wrapper.node, tag.node)
+	tag.node.Parent.RemoveChild(tag.node)
+	wrapper.node.AppendChild(tag.node)
+
+	return nil
+}
+
+// Check whether given tag can be inserted next to current tag
+func (tag *Tag) checkSibling(sibling *Tag) error {
+	if tag.node.Parent == nil {
+		return errors.New("tag has no parent")
+	}
+	if sibling != nil && sibling.node == tag.node {
+		return errors.New("cannot insert tag next to itself")
+	}
+	return checkInsert(tag.node.Parent, sibling)
+}
+
+// Check whether given tag can be inserted into parent node without a cycle
+func checkInsert(parent *html.Node, child *Tag) error {
+	if child == nil {
+		return errors.New("inserted tag is nil")
+	}
+	for node := parent; node != nil; node = node.Parent {
+		if node == child.node {
+			return errors.New("cannot insert tag into itself or its descendant")
+		}
+	}
+	return nil
+}
+
+// Get attribute value and whether attribute is present
+func (tag *Tag) GetAttr(key string) (string, bool) {
+	value, ok := tag.Attrs[key]
+	return value, ok
+}
+
+// Get data-* attributes with the prefix stripped, e.g. data-user-id
+// becomes user-id. Returns empty map if tag has no data attributes
+func (tag *Tag) DataAttrs() map[string]string {
+	data := make(map[string]string)
+	for key, value := range tag.Attrs {
+		if name, ok := strings.CutPrefix(key, "data-"); ok {
+			data[name] = value
+		}
+	}
+	return data
+}
+
+// Get value of data-* attribute by name without the prefix
+// and whether attribute is present
+func (tag *Tag) Data(name string) (string, bool) {
+	return tag.GetAttr("data-" + name)
+}
+
+// Get namespaced attribute value and whether attribute is present.
+// Empty namespace matches plain attributes only
+func (tag *Tag) GetAttrNS(namespace, key string) (string, bool) {
+	for _, attr := range tag.node.Attr {
+		if attr.Namespace == namespace && attr.Key == key {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// Tag attribute as it appears in the source.
+// Namespace is set only for foreign content attributes like xlink:href
+type Attr struct {
+	Namespace string
+	Key       string
+	Val       string
+}
+
+// Get attributes in their original order.
+// Returns empty slice if tag has no attributes
+func (tag *Tag) AttrsOrdered() []Attr {
+	attrs := make([]Attr, 0, len(tag.node.Attr))
+	for _, attr := range tag.node.Attr {
+		attrs = append(attrs, Attr{Namespace: attr.Namespace, Key: attr.Key, Val: attr.Val})
+	}
+	return attrs
+}
+
+// Get classes of a tag, split on any ASCII whitespace.
+// Returns empty slice if tag has no classes
+func (tag *Tag) ClassList() []string {
+	classes := strings.FieldsFunc(tag.Attrs["class"], func(r rune) bool {
+		return r < 0x80 && isASCIISpace(byte(r))
+	})
+	if classes == nil {
+		classes = []string{}
+	}
+	return classes
+}
+
+// Set attribute value, overwriting existing one.
+// Underlying node is updated too, so rendering reflects the change
+func (tag *Tag) SetAttr(key, value string) {
+	tag.Attrs[key] = value
+
+	for i, attr := range tag.node.Attr {
+		if attr.Namespace == "" && attr.Key == key {
+			tag.node.Attr[i].Val = value
+			return
+		}
+	}
+	tag.node.Attr = append(tag.node.Attr, html.Attribute{Key: key, Val: value})
+}
+
+// Remove attribute, doing nothing if it is absent.
+// Underlying node is updated too, so rendering reflects the change
+func (tag *Tag) RemoveAttr(key string) {
+	delete(tag.Attrs, key)
+
+	attrs := tag.node.Attr[:0]
+	for _, attr := range tag.node.Attr {
+		if attr.Namespace == "" && attr.Key == key {
+			continue
+		}
+		attrs = append(attrs, attr)
+	}
+	tag.node.Attr = attrs
+}
+
+// Add class to a tag unless it is already present
+func (tag *Tag) AddClass(class string) {
+	classes := tag.ClassList()
+	for _, entry := range classes {
+		if entry == class {
+			return
+		}
+	}
+	tag.SetAttr("class", strings.Join(append(classes, class), " "))
+}
+
+// Remove all occurrences of class from a tag.
+// Class attribute is removed once no classes are left
+func (tag *Tag) RemoveClass(class string) {
+	if _, ok := tag.Attrs["class"]; !ok {
+		return
+	}
+
+	var classes []string
+	for _, entry := range tag.ClassList() {
+		if entry != class {
+			classes = append(classes, entry)
+		}
+	}
+
+	if len(classes) == 0 {
+		tag.RemoveAttr("class")
+		return
+	}
+	tag.SetAttr("class", strings.Join(classes, " "))
+}
+
+// Find first descendant tag matching predicate, in document order.
+// Current tag itself is never returned
+func (tag *Tag) Find(predicate Predicate) *Tag {
+	checkPredicate(predicate)
 
+	matcher := tag.doc.newMatcher(predicate)
 
-	return find(tag, true)
+	var result *Tag
+	walkElements(tag.node, func(node *html.Node, _ int) (bool, bool) {
+		result = matcher.match(node)
+		return true, result == nil
+	})
+
+	return result
 }
 
 // Find all children tags by predicate
 func (tag *Tag) FindAll(predicate Predicate) []*Tag {
-	var result []*Tag
+	checkPredicate(predicate)
+
+	matcher := tag.doc.newMatcher(predicate)
 
-	var find func(*Tag, bool)
-	find = func(t *Tag, skipCheck bool) {
-		if !skipCheck && predicate(t) {
-			result = append(result, t)
+	var result []*Tag
+	walkElements(tag.node, func(node *html.Node, _ int) (bool, bool) {
+		if found := matcher.match(node); found != nil {
+			result = append(result, found)
 		}
+		return true, true
+	})
+
+	return result
+}
+
+// Find all children tags matching any of predicates, in document order.
+// Each tag is returned once, even if it matches several predicates
+func (tag *Tag) FindAllAny(predicates ...Predicate) []*Tag {
+	for _, predicate := range predicates {
+		checkPredicate(predicate)
+	}
+	return tag.FindAll(Any(predicates...))
+}
+
+// Find first direct child tag by predicate, not descending further
+func (tag *Tag) FindChild(predicate Predicate) *Tag {
+	checkPredicate(predicate)
 
-		for child := t.FirstChild(); child != nil; child = child.Next() {
-			find(child, false)
+	for child := tag.FirstChild(); child != nil; child = child.Next() {
+		if predicate(child) {
+			return child
 		}
 	}
+	return nil
+}
 
-	find(tag, true)
+// Find all direct children tags by predicate, not descending further
+func (tag *Tag) FindChildren(predicate Predicate) []*Tag {
+	checkPredicate(predicate)
 
+	var result []*Tag
+	for child := tag.FirstChild(); child != nil; child = child.Next() {
+		if predicate(child) {
+			result = append(result, child)
+		}
+	}
 	return result
 }
 
-// Find parent tag by predicate
-func (tag *Tag) FindParent(predicate Predicate) *Tag {
-	var find func(*Tag) *Tag
+// Count all children tags matching predicate without collecting them
+func (tag *Tag) Count(predicate Predicate) int {
+	checkPredicate(predicate)
+
+	matcher := tag.doc.newMatcher(predicate)
 
-	find = func(t *Tag) *Tag {
-		if t == nil {
-			return nil
+	count := 0
+	walkElements(tag.node, func(node *html.Node, _ int) (bool, bool) {
+		if matcher.test(node) {
+			count++
 		}
-		if predicate(t) {
-			return t
+		return true, true
+	})
+
+	return count
+}
+
+// Find at most limit children tags by predicate, stopping traversal
+// once limit is reached. Zero or negative limit means no limit
+func (tag *Tag) FindAllLimit(predicate Predicate, limit int) []*Tag {
+	checkPredicate(predicate)
+
+	if limit <= 0 {
+		return tag.FindAll(predicate)
+	}
+
+	matcher := tag.doc.newMatcher(predicate)
+
+	var result []*Tag
+	walkElements(tag.node, func(node *html.Node, _ int) (bool, bool) {
+		if found := matcher.match(node); found != nil {
+			result = append(result, found)
 		}
+		return true, len(result) < limit
+	})
 
-		for parent := t.Parent(); parent != nil; parent = parent.Parent() {
-			if found := find(parent); found != nil {
-				return found
+	return result
+}
+
+// Iterate through all children tags matching predicate,
+// without collecting them into a slice
+func (tag *Tag) FindAllSeq(predicate Predicate) iter.Seq[*Tag] {
+	checkPredicate(predicate)
+
+	return func(yield func(*Tag) bool) {
+		matcher := tag.doc.newMatcher(predicate)
+
+		walkElements(tag.node, func(node *html.Node, _ int) (bool, bool) {
+			if found := matcher.match(node); found != nil {
+				return true, yield(found)
 			}
-		}
+			return true, true
+		})
+	}
+}
 
-		return nil
+// Find parent tag by predicate
+func (tag *Tag) FindParent(predicate Predicate) *Tag {
+	checkPredicate(predicate)
+
+	for parent := tag.Parent(); parent != nil; parent = parent.Parent() {
+		if predicate(parent) {
+			return parent
+		}
 	}
 
-	return find(tag.Parent())
+	return nil
+}
+
+// Find closest tag matching predicate, checking current tag first
+// and then its parents, like Element.closest in the DOM
+func (tag *Tag) Closest(predicate Predicate) *Tag {
+	checkPredicate(predicate)
+
+	if predicate(tag) {
+		return tag
+	}
+	return tag.FindParent(predicate)
 }
 
 // Iterate through all children nodes of current tag,
@@ -255,3 +780,958 @@ func (tag *Tag) IterNodes() iter.Seq[Node] {
 		traverse(tag.node)
 	}
 }
+
+// Render attributes of tag as they appear in a start tag,
+// keeping source order and escaping values
+func (tag *Tag) AttrsString() string {
+	var builder strings.Builder
+	writeAttrs(&builder, tag.node.Attr)
+	return builder.String()
+}
+
+// Render only start tag of a current tag with attributes in source order.
+// Void elements are rendered self-closing, the same way as String does
+func (tag *Tag) OpenTag() string {
+	var builder strings.Builder
+	writeStartTag(&builder, tag.node)
+
+	if isVoidElement(tag.Name) {
+		return strings.TrimSuffix(builder.String(), ">") + "/>"
+	}
+	return builder.String()
+}
+
+// Write attributes separated by spaces, escaping values
+func writeAttrs(builder *strings.Builder, attrs []html.Attribute) {
+	for i, attr := range attrs {
+		if i > 0 {
+			builder.WriteByte(' ')
+		}
+		if attr.Namespace != "" {
+			builder.WriteString(attr.Namespace)
+			builder.WriteByte(':')
+		}
+		builder.WriteString(attr.Key)
+		builder.WriteString(`="`)
+		builder.WriteString(html.EscapeString(attr.Val))
+		builder.WriteByte('"')
+	}
+}
+
+// Re-parse content of a <noscript> tag as HTML and return root of the result.
+// With scripting enabled, x/net/html keeps <noscript> content as raw text,
+// so Find cannot see fallback markup inside it. The content is parsed as
+// a separate document, so changes to the returned tree don't affect
+// the original one. Returns nil if tag is not <noscript> or has no content
+func (tag *Tag) NoscriptContent() *Tag {
+	if tag.Name != "noscript" {
+		return nil
+	}
+
+	var builder strings.Builder
+	for child := tag.node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.TextNode {
+			builder.WriteString(child.Data)
+			continue
+		}
+		html.Render(&builder, child)
+	}
+
+	doc, err := ParseString(builder.String())
+	if err != nil {
+		return nil
+	}
+
+	return doc.Root()
+}
+
+// Find child tag by match predicate, pruning subtrees by stop predicate.
+// Descendants are walked in document order; a tag satisfying stop
+// is neither matched nor descended into, so nothing inside it is returned.
+// The current tag itself is checked by neither predicate
+func (tag *Tag) FindUntil(match, stop Predicate) *Tag {
+	checkPredicate(match)
+	checkPredicate(stop)
+
+	matcher := tag.doc.newMatcher(match)
+	stopper := tag.doc.newMatcher(stop)
+
+	var result *Tag
+	walkElements(tag.node, func(node *html.Node, _ int) (bool, bool) {
+		if stopper.test(node) {
+			return false, true
+		}
+		result = matcher.match(node)
+		return true, result == nil
+	})
+
+	return result
+}
+
+// Get next tag in document order, crossing parent boundaries
+func (tag *Tag) NextInDocument() *Tag {
+	if child := tag.FirstChild(); child != nil {
+		return child
+	}
+	for t := tag; t != nil; t = t.Parent() {
+		if next := t.Next(); next != nil {
+			return next
+		}
+	}
+	return nil
+}
+
+// Get previous tag in document order, crossing parent boundaries
+func (tag *Tag) PrevInDocument() *Tag {
+	prev := tag.Prev()
+	if prev == nil {
+		return tag.Parent()
+	}
+	for last := prev.lastChild(); last != nil; last = last.lastChild() {
+		prev = last
+	}
+	return prev
+}
+
+// Get a last child of a tag
+func (tag *Tag) lastChild() *Tag {
+	for child := tag.node.LastChild; child != nil; child = child.PrevSibling {
+		if child.Type == html.ElementNode {
+			return tag.doc.newTag(child)
+		}
+	}
+	return nil
+}
+
+// Image candidate from srcset attribute
+type SrcSetCandidate struct {
+	URL        string
+	Descriptor string
+}
+
+// Get parsed image candidates from srcset attribute.
+// Descriptor is kept as is (e.g. "480w" or "2x") and is empty if omitted.
+// Returns nil if attribute is absent
+func (tag *Tag) SrcSet() []SrcSetCandidate {
+	value, ok := tag.Attrs["srcset"]
+	if !ok {
+		return nil
+	}
+
+	var candidates []SrcSetCandidate
+
+	pos := 0
+	for pos < len(value) {
+		for pos < len(value) && (isASCIISpace(value[pos]) || value[pos] == ',') {
+			pos++
+		}
+		if pos >= len(value) {
+			break
+		}
+
+		start := pos
+		for pos < len(value) && !isASCIISpace(value[pos]) {
+			pos++
+		}
+		url := value[start:pos]
+
+		descriptor := ""
+		if strings.HasSuffix(url, ",") {
+			// Trailing commas end the candidate without descriptors
+			url = strings.TrimRight(url, ",")
+		} else {
+			start = pos
+			inParens := false
+			for ; pos < len(value); pos++ {
+				c := value[pos]
+				if c == '(' {
+					inParens = true
+				} else if c == ')' {
+					inParens = false
+				} else if c == ',' && !inParens {
+					break
+				}
+			}
+			descriptor = strings.Join(strings.Fields(value[start:pos]), " ")
+		}
+
+		if url != "" {
+			candidates = append(candidates, SrcSetCandidate{URL: url, Descriptor: descriptor})
+		}
+	}
+
+	return candidates
+}
+
+// Check whether byte is an ASCII whitespace as defined by HTML spec
+func isASCIISpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\f' || c == '\r'
+}
+
+// Get uppercased method and action URL of a <form> tag.
+// As in browsers, missing or invalid method falls back to GET.
+// Returns empty strings if tag is not <form>
+func (tag *Tag) FormAction() (method, action string) {
+	if tag.Name != "form" {
+		return "", ""
+	}
+
+	method = strings.ToUpper(strings.TrimSpace(tag.Attrs["method"]))
+	switch method {
+	case "GET", "POST", "DIALOG":
+	default:
+		method = "GET"
+	}
+
+	return method, tag.Attrs["action"]
+}
+
+// Render a tree with a current tag as root, collapsing runs of whitespace
+// in text to a single space. Content of whitespace-sensitive tags
+// (<pre>, <textarea>, <script>, etc.) is rendered as is
+func (tag *Tag) RenderCollapsed(w io.Writer) error {
+	for node := tag.node; node != nil; node = node.Parent {
+		if node.Type == html.ElementNode && preservesSpace(node.Data) {
+			return html.Render(w, tag.node)
+		}
+	}
+
+	clone := cloneNode(tag.node)
+
+	var collapse func(*html.Node)
+	collapse = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			switch child.Type {
+			case html.TextNode:
+				child.Data = collapseSpace(child.Data)
+			case html.ElementNode:
+				if !preservesSpace(child.Data) {
+					collapse(child)
+				}
+			}
+		}
+	}
+	collapse(clone)
+
+	return html.Render(w, clone)
+}
+
+// Check whether whitespace inside tag with given name is significant
+func preservesSpace(name string) bool {
+	switch name {
+	case "pre", "textarea", "listing", "plaintext", "xmp", "script", "style":
+		return true
+	}
+	return false
+}
+
+// Check whether text inside tag with given name is raw, i.e. rendered
+// without escaping, the same set golang.org/x/net/html renders literally
+func isRawTextElement(name string) bool {
+	switch name {
+	case "iframe", "noembed", "noframes", "noscript", "plaintext", "script", "style", "xmp":
+		return true
+	}
+	return false
+}
+
+// Replace every run of ASCII whitespace with a single space
+func collapseSpace(s string) string {
+	var builder strings.Builder
+
+	space := false
+	for i := 0; i < len(s); i++ {
+		if isASCIISpace(s[i]) {
+			if !space {
+				builder.WriteByte(' ')
+				space = true
+			}
+			continue
+		}
+		space = false
+		builder.WriteByte(s[i])
+	}
+
+	return builder.String()
+}
+
+// Make a deep copy of node, detached from any tree
+func cloneNode(node *html.Node) *html.Node {
+	clone := &html.Node{
+		Type:      node.Type,
+		DataAtom:  node.DataAtom,
+		Data:      node.Data,
+		Namespace: node.Namespace,
+		Attr:      append([]html.Attribute(nil), node.Attr...),
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		clone.AppendChild(cloneNode(child))
+	}
+	return clone
+}
+
+// Find descendant tag by predicate, same as Find.
+// Unlike self-inclusive searches, current tag itself is never returned
+func (tag *Tag) FindDescendant(predicate Predicate) *Tag {
+	return tag.Find(predicate)
+}
+
+// Found tag with its depth relative to the search root
+type DepthTag struct {
+	Tag   *Tag
+	Depth int
+}
+
+// Find all children tags by predicate, recording depth of each match
+// relative to current tag (direct children have depth 1)
+func (tag *Tag) FindAllWithDepth(predicate Predicate) []DepthTag {
+	checkPredicate(predicate)
+
+	matcher := tag.doc.newMatcher(predicate)
+
+	var result []DepthTag
+	walkElements(tag.node, func(node *html.Node, depth int) (bool, bool) {
+		if found := matcher.match(node); found != nil {
+			result = append(result, DepthTag{Tag: found, Depth: depth})
+		}
+		return true, true
+	})
+
+	return result
+}
+
+// Find form control associated with a <label> with given text.
+// Label text is compared with whitespace collapsed and trimmed,
+// ignoring text of controls nested in the label. Both explicit
+// (for="id") and implicit (label wraps control) associations are supported.
+// Current tag itself is checked too if it is a label. Explicit targets
+// are looked up in the whole tree containing the label
+func (tag *Tag) FindByLabel(text string) *Tag {
+	labels := tag.FindAll(HasName("label"))
+	if tag.Name == "label" {
+		labels = append([]*Tag{tag}, labels...)
+	}
+
+	for _, label := range labels {
+		if labelText(label.node) != text {
+			continue
+		}
+
+		if id, ok := label.Attrs["for"]; ok {
+			top := label.node
+			for top.Parent != nil {
+				top = top.Parent
+			}
+			control := label.doc.newTag(findElementByID(top, id))
+			if control != nil && isLabelable(control) {
+				return control
+			}
+			continue
+		}
+
+		if control := label.Find(isLabelable); control != nil {
+			return control
+		}
+	}
+	return nil
+}
+
+// Find first element node with given id in a tree of given node,
+// including the node itself
+func findElementByID(node *html.Node, id string) *html.Node {
+	if node.Type == html.ElementNode {
+		for _, attr := range node.Attr {
+			if attr.Namespace == "" && attr.Key == "id" && attr.Val == id {
+				return node
+			}
+		}
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if found := findElementByID(child, id); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// Collect text of a label, skipping text of nested controls
+func labelText(node *html.Node) string {
+	builder := &strings.Builder{}
+
+	var traverse func(*html.Node)
+	traverse = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			switch child.Type {
+			case html.TextNode:
+				builder.WriteString(child.Data)
+			case html.ElementNode:
+				switch child.Data {
+				case "button", "meter", "output", "progress", "select", "textarea":
+					continue
+				}
+				traverse(child)
+			}
+		}
+	}
+
+	traverse(node)
+
+	return strings.TrimSpace(collapseSpace(builder.String()))
+}
+
+// Check whether tag is a labelable form control
+func isLabelable(tag *Tag) bool {
+	switch tag.Name {
+	case "button", "meter", "output", "progress", "select", "textarea":
+		return true
+	case "input":
+		return !strings.EqualFold(tag.Attrs["type"], "hidden")
+	}
+	return false
+}
+
+// Get content of a <script> tag verbatim, without unescaping.
+// Returns empty string if tag is not <script>
+func (tag *Tag) ScriptContent() string {
+	if tag.Name != "script" {
+		return ""
+	}
+	return tag.rawContent()
+}
+
+// Get content of a <style> tag verbatim, without unescaping.
+// Returns empty string if tag is not <style>
+func (tag *Tag) StyleContent() string {
+	if tag.Name != "style" {
+		return ""
+	}
+	return tag.rawContent()
+}
+
+// Concatenate all direct text nodes of a raw text tag
+func (tag *Tag) rawContent() string {
+	var builder strings.Builder
+	for node := tag.node.FirstChild; node != nil; node = node.NextSibling {
+		if node.Type == html.TextNode {
+			builder.WriteString(node.Data)
+		}
+	}
+	return builder.String()
+}
+
+// Number of visited tags between context checks in FindCtx
+const ctxCheckInterval = 256
+
+// Find child tag by predicate, stopping early if context is done.
+// Context is checked every few hundred visited tags to keep overhead low,
+// ctx.Err() is returned on cancellation
+func (tag *Tag) FindCtx(ctx context.Context, predicate Predicate) (*Tag, error) {
+	checkPredicate(predicate)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	matcher := tag.doc.newMatcher(predicate)
+	visited := 0
+
+	var result *Tag
+	var err error
+	walkElements(tag.node, func(node *html.Node, _ int) (bool, bool) {
+		visited++
+		if visited%ctxCheckInterval == 0 {
+			if err = ctx.Err(); err != nil {
+				return false, false
+			}
+		}
+
+		result = matcher.match(node)
+		return true, result == nil
+	})
+
+	return result, err
+}
+
+// Count non-overlapping occurrences of substr in text of a current tree,
+// as strings.Count does. Each text node is counted separately, so matches
+// never span element boundaries. Content of <script>, <style>, <noscript>
+// and <template> tags is skipped, empty substr is never counted
+func (tag *Tag) CountText(substr string) int {
+	if substr == "" {
+		return 0
+	}
+
+	count := 0
+
+	var traverse func(*html.Node)
+	traverse = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			switch child.Type {
+			case html.TextNode:
+				count += strings.Count(child.Data, substr)
+			case html.ElementNode:
+				if !isHiddenElement(child.Data) {
+					traverse(child)
+				}
+			}
+		}
+	}
+
+	traverse(tag.node)
+
+	return count
+}
+
+// Get values of given attribute from all children tags having it,
+// in document order
+func (tag *Tag) AttrValues(key string) []string {
+	var values []string
+
+	var traverse func(*html.Node)
+	traverse = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			for _, attr := range child.Attr {
+				if attr.Namespace == "" && attr.Key == key {
+					values = append(values, attr.Val)
+					break
+				}
+			}
+			traverse(child)
+		}
+	}
+
+	traverse(tag.node)
+
+	return values
+}
+
+// Get data of all comments in a current tree, in document order.
+// Comment data is returned as is, without surrounding whitespace trimmed
+func (tag *Tag) Comments() []string {
+	var comments []string
+
+	var traverse func(*html.Node)
+	traverse = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			switch child.Type {
+			case html.CommentNode:
+				comments = append(comments, child.Data)
+			case html.ElementNode:
+				traverse(child)
+			}
+		}
+	}
+
+	traverse(tag.node)
+
+	return comments
+}
+
+// Build a map from all children tags matching predicate, using keyFn and
+// valFn to compute entries. On duplicate keys the last match wins
+func (tag *Tag) CollectMap(keyFn, valFn func(*Tag) string, predicate Predicate) map[string]string {
+	result := make(map[string]string)
+	for _, found := range tag.FindAll(predicate) {
+		result[keyFn(found)] = valFn(found)
+	}
+	return result
+}
+
+// Get byte length of rendered tree without building the string
+func (tag *Tag) RenderedSize() int {
+	var counter countingWriter
+	tag.Render(&counter)
+	return int(counter)
+}
+
+// Writer that only counts written bytes
+type countingWriter int
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	*w += countingWriter(len(p))
+	return len(p), nil
+}
+
+// Get first following sibling tag with given name
+func (tag *Tag) NextElementNamed(name string) *Tag {
+	return tag.FindNextSibling(HasName(name))
+}
+
+// Get all descendant tags recursively, in document order
+func (tag *Tag) AllDescendants() []*Tag {
+	return slices.Collect(tag.Descendants())
+}
+
+// Walk a tree with current tag as root in document order, calling enter
+// before children of a tag and exit after them. Returning false from enter
+// skips children of a tag, exit is still called for it. Returning false
+// from exit stops the walk. Either callback may be nil
+func (tag *Tag) Walk(enter, exit func(*Tag) bool) {
+	var walk func(*Tag) bool
+	walk = func(t *Tag) bool {
+		if enter == nil || enter(t) {
+			for child := t.FirstChild(); child != nil; child = child.Next() {
+				if !walk(child) {
+					return false
+				}
+			}
+		}
+		return exit == nil || exit(t)
+	}
+
+	walk(tag)
+}
+
+// Iterate through all descendant tags recursively, in document order,
+// excluding current tag
+func (tag *Tag) Descendants() iter.Seq[*Tag] {
+	return func(yield func(*Tag) bool) {
+		var traverse func(*Tag) bool
+		traverse = func(t *Tag) bool {
+			for child := t.FirstChild(); child != nil; child = child.Next() {
+				if !yield(child) {
+					return false
+				}
+				if !traverse(child) {
+					return false
+				}
+			}
+			return true
+		}
+
+		traverse(tag)
+	}
+}
+
+// Iterate through all descendant tags level by level, excluding
+// current tag. Tags of the same level are yielded in document order
+func (tag *Tag) DescendantsBFS() iter.Seq[*Tag] {
+	return func(yield func(*Tag) bool) {
+		queue := []*Tag{tag}
+		for len(queue) > 0 {
+			t := queue[0]
+			queue = queue[1:]
+
+			for child := t.FirstChild(); child != nil; child = child.Next() {
+				if !yield(child) {
+					return
+				}
+				queue = append(queue, child)
+			}
+		}
+	}
+}
+
+// Iterate through parent tags, from nearest one up to the root
+func (tag *Tag) Ancestors() iter.Seq[*Tag] {
+	return func(yield func(*Tag) bool) {
+		for parent := tag.Parent(); parent != nil; parent = parent.Parent() {
+			if !yield(parent) {
+				return
+			}
+		}
+	}
+}
+
+// Get next sibling node of tag, either tag or raw string.
+// Comments and other node types are skipped
+func (tag *Tag) NextNode() Node {
+	for next := tag.node.NextSibling; next != nil; next = next.NextSibling {
+		if node := tag.doc.newNode(next); node != nil {
+			return node
+		}
+	}
+	return nil
+}
+
+// Get previous sibling node of tag, either tag or raw string.
+// Comments and other node types are skipped
+func (tag *Tag) PrevNode() Node {
+	for prev := tag.node.PrevSibling; prev != nil; prev = prev.PrevSibling {
+		if node := tag.doc.newNode(prev); node != nil {
+			return node
+		}
+	}
+	return nil
+}
+
+// Find following sibling tag by predicate, without descending into siblings
+func (tag *Tag) FindNextSibling(predicate Predicate) *Tag {
+	checkPredicate(predicate)
+
+	for next := tag.Next(); next != nil; next = next.Next() {
+		if predicate(next) {
+			return next
+		}
+	}
+	return nil
+}
+
+// Find preceding sibling tag by predicate, without descending into siblings
+func (tag *Tag) FindPrevSibling(predicate Predicate) *Tag {
+	checkPredicate(predicate)
+
+	for prev := tag.Prev(); prev != nil; prev = prev.Prev() {
+		if predicate(prev) {
+			return prev
+		}
+	}
+	return nil
+}
+
+// Find all following sibling tags by predicate, in document order
+func (tag *Tag) FindAllNextSiblings(predicate Predicate) []*Tag {
+	checkPredicate(predicate)
+
+	var result []*Tag
+	for next := tag.Next(); next != nil; next = next.Next() {
+		if predicate(next) {
+			result = append(result, next)
+		}
+	}
+	return result
+}
+
+// Get all sibling tags in document order, excluding current tag.
+// Returns empty slice if tag is detached from a tree
+func (tag *Tag) Siblings() []*Tag {
+	siblings := []*Tag{}
+	if tag.node.Parent == nil {
+		return siblings
+	}
+
+	for node := tag.node.Parent.FirstChild; node != nil; node = node.NextSibling {
+		if node.Type == html.ElementNode && node != tag.node {
+			siblings = append(siblings, tag.doc.newTag(node))
+		}
+	}
+	return siblings
+}
+
+// Get following sibling tags up to the first one matching predicate,
+// which is not included. If no sibling matches, all following
+// siblings are returned
+func (tag *Tag) SiblingsUntil(predicate Predicate) []*Tag {
+	checkPredicate(predicate)
+
+	var result []*Tag
+	for next := tag.Next(); next != nil && !predicate(next); next = next.Next() {
+		result = append(result, next)
+	}
+	return result
+}
+
+// Write a tree with a current tag as root to given writer as indented JSON.
+// Tags and comments are objects with "type" and their name, attributes,
+// data and children, text nodes are plain strings. Attributes are
+// written sorted by key, so output is stable for the same tree
+func (tag *Tag) DumpJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(dumpNode(tag.node))
+}
+
+// Node of a tree as written by DumpJSON
+type jsonNode struct {
+	Type     string            `json:"type"`
+	Name     string            `json:"name,omitempty"`
+	Attrs    map[string]string `json:"attrs,omitempty"`
+	Data     string            `json:"data,omitempty"`
+	Children []any             `json:"children,omitempty"`
+}
+
+// Convert element or comment node into jsonNode and text node into string
+func dumpNode(node *html.Node) any {
+	switch node.Type {
+	case html.TextNode:
+		return node.Data
+	case html.CommentNode:
+		return &jsonNode{Type: "comment", Data: node.Data}
+	}
+
+	dumped := &jsonNode{Type: "element", Name: node.Data}
+	if len(node.Attr) > 0 {
+		dumped.Attrs = make(map[string]string, len(node.Attr))
+		for _, attr := range node.Attr {
+			key := attr.Key
+			if attr.Namespace != "" {
+				key = attr.Namespace + ":" + key
+			}
+			dumped.Attrs[key] = attr.Val
+		}
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode || child.Type == html.TextNode || child.Type == html.CommentNode {
+			dumped.Children = append(dumped.Children, dumpNode(child))
+		}
+	}
+
+	return dumped
+}
+
+// Render a tree with a current tag as root, putting each tag and text
+// on its own line indented by depth. Text is trimmed and whitespace in it
+// is collapsed, tags with text and comments only are kept on one line,
+// and content of whitespace-sensitive and raw text tags (<pre>, <script>,
+// <noscript>, etc.) is rendered as is
+func (tag *Tag) PrettyString(indent string) string {
+	var builder strings.Builder
+
+	var render func(*html.Node, int)
+	render = func(node *html.Node, depth int) {
+		switch node.Type {
+		case html.TextNode:
+			text := strings.TrimSpace(collapseSpace(node.Data))
+			if text == "" {
+				return
+			}
+			writeIndent(&builder, indent, depth)
+			builder.WriteString(html.EscapeString(text))
+			return
+		case html.ElementNode:
+		default:
+			writeIndent(&builder, indent, depth)
+			html.Render(&builder, node)
+			return
+		}
+
+		writeIndent(&builder, indent, depth)
+		if preservesSpace(node.Data) || isRawTextElement(node.Data) || isVoidElement(node.Data) {
+			html.Render(&builder, node)
+			return
+		}
+
+		writeStartTag(&builder, node)
+		if hasElementChild(node) {
+			for child := node.FirstChild; child != nil; child = child.NextSibling {
+				render(child, depth+1)
+			}
+			writeIndent(&builder, indent, depth)
+		} else {
+			var inline strings.Builder
+			for child := node.FirstChild; child != nil; child = child.NextSibling {
+				if child.Type == html.TextNode {
+					inline.WriteString(html.EscapeString(collapseSpace(child.Data)))
+				} else {
+					html.Render(&inline, child)
+				}
+			}
+			builder.WriteString(strings.Trim(inline.String(), " "))
+		}
+		builder.WriteString("</")
+		builder.WriteString(node.Data)
+		builder.WriteByte('>')
+	}
+
+	render(tag.node, 0)
+
+	return strings.TrimPrefix(builder.String(), "\n")
+}
+
+// Start new line with indent repeated depth times
+func writeIndent(builder *strings.Builder, indent string, depth int) {
+	builder.WriteByte('\n')
+	for range depth {
+		builder.WriteString(indent)
+	}
+}
+
+// Write start tag of node with its attributes
+func writeStartTag(builder *strings.Builder, node *html.Node) {
+	builder.WriteByte('<')
+	builder.WriteString(node.Data)
+	if len(node.Attr) > 0 {
+		builder.WriteByte(' ')
+		writeAttrs(builder, node.Attr)
+	}
+	builder.WriteByte('>')
+}
+
+// Check whether node has any element children
+func hasElementChild(node *html.Node) bool {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode {
+			return true
+		}
+	}
+	return false
+}
+
+// Check whether tag with given name is a void element, which has no content
+func isVoidElement(name string) bool {
+	switch name {
+	case "area", "base", "br", "col", "embed", "hr", "img", "input",
+		"keygen", "link", "meta", "param", "source", "track", "wbr":
+		return true
+	}
+	return false
+}
+
+// Check whether tag with given name starts a new line when rendered
+func isBlockElement(name string) bool {
+	switch name {
+	case "address", "article", "aside", "blockquote", "body", "dd", "details",
+		"dialog", "div", "dl", "dt", "fieldset", "figcaption", "figure",
+		"footer", "form", "h1", "h2", "h3", "h4", "h5", "h6", "header",
+		"hgroup", "hr", "html", "li", "main", "nav", "ol", "p", "pre",
+		"section", "summary", "table", "tr", "ul":
+		return true
+	}
+	return false
+}
+
+// Check whether other tag is current tag or its descendant
+func (tag *Tag) Contains(other *Tag) bool {
+	if other == nil {
+		return false
+	}
+	for node := other.node; node != nil; node = node.Parent {
+		if node == tag.node {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns zero-based position of tag among its sibling tags,
+// or -1 if tag is detached from a tree
+func (tag *Tag) Index() int {
+	if tag.node.Parent == nil {
+		return -1
+	}
+
+	index := 0
+	for prev := tag.node.PrevSibling; prev != nil; prev = prev.PrevSibling {
+		if prev.Type == html.ElementNode {
+			index++
+		}
+	}
+
+	return index
+}
+
+// Returns path of sibling indices from the topmost tag down to current one,
+// as given by Index. Paths of tags from the same tree compare
+// lexicographically in document order, see ComparePosition
+func (tag *Tag) Position() []int {
+	var position []int
+	for t := tag; t != nil; t = t.Parent() {
+		if index := t.Index(); index >= 0 {
+			position = append(position, index)
+		}
+	}
+	slices.Reverse(position)
+	return position
+}
+
+// Compare positions of two tags from the same tree in document order.
+// Returns -1 if a comes before b, 1 if a comes after b and 0 if
+// they are the same tag. Ancestors come before their descendants
+func ComparePosition(a, b *Tag) int {
+	return slices.Compare(a.Position(), b.Position())
+}
@@ -1,6 +1,10 @@
 package gosoup
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -182,6 +186,54 @@ func TestFullTextWithSeparator(t *testing.T) {
 	}
 }
 
+func TestFullTextSeparatorJoins(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	p := root.Find(HasClass("a"))
+	if p == nil {
+		t.Fatalf("could not find paragraph with class 'a'")
+	}
+
+	if text := p.FullText(" | "); text != "Hello  | World" {
+		t.Fatalf("expected separator only between fragments, got %q", text)
+	}
+	if text := p.FullText(); text != "Hello World" {
+		t.Fatalf("expected plain concatenation without separator, got %q", text)
+	}
+}
+
+func TestFullTextNormalized(t *testing.T) {
+	html := `
+	<div>
+		<p>
+			Hello
+			<span>World</span>
+		</p>
+	</div>
+	`
+
+	doc, err := ParseString(html)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	div := root.Find(HasName("div"))
+	if div == nil {
+		t.Fatalf("could not find div")
+	}
+
+	if text := div.FullTextNormalized(); text != "Hello World" {
+		t.Fatalf("expected 'Hello World', got %q", text)
+	}
+}
+
 func TestString(t *testing.T) {
 	doc, err := ParseString(sampleHTML)
 	if err != nil {
@@ -201,6 +253,179 @@ func TestString(t *testing.T) {
     }
 }
 
+func TestRender(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	p := root.Find(AttrEq("class", "a b"))
+	if p == nil {
+		t.Fatalf("could not find p")
+	}
+
+	var builder strings.Builder
+	if err := p.Render(&builder); err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+	if text := builder.String(); text != p.String() {
+		t.Fatalf("expected Render to match String, got: %s", text)
+	}
+
+	if err := p.Render(failingWriter{}); err == nil {
+		t.Fatalf("expected write error to be returned")
+	}
+}
+
+func TestVisibleText(t *testing.T) {
+	doc, err := ParseString(`<html><head><style>p { color: red }</style></head><body>
+<p>Hello</p><script>var x=1</script><noscript>Enable JS</noscript><template><p>Hidden</p></template><p>World</p>
+</body></html>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	text := root.VisibleText("|")
+	if text != "\n|Hello|World|\n" {
+		t.Fatalf("unexpected visible text %q", text)
+	}
+	for _, hidden := range []string{"var x=1", "color", "Enable JS", "Hidden"} {
+		if strings.Contains(text, hidden) {
+			t.Fatalf("expected %q to be skipped, got %q", hidden, text)
+		}
+	}
+
+	if !strings.Contains(root.FullText(), "var x=1") {
+		t.Fatalf("expected FullText to keep script content")
+	}
+	if text := root.Find(HasName("script")).VisibleText(); text != "" {
+		t.Fatalf("expected no visible text in script, got %q", text)
+	}
+}
+
+func TestInnerHTML(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	p := root.Find(AttrEq("class", "a b"))
+	if p == nil {
+		t.Fatalf("could not find p")
+	}
+
+	if inner := p.InnerHTML(); inner != "Hello <span>World</span>" {
+		t.Fatalf("InnerHTML failed: got: %s", inner)
+	}
+	if inner := root.Find(HasName("head")).InnerHTML(); inner != "" {
+		t.Fatalf("expected empty inner HTML, got: %s", inner)
+	}
+
+	script := `if (a < b && c) {}`
+	doc, err = ParseString("<script>" + script + "</script><style>a > b {}</style>")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if inner := doc.Root().Find(HasName("script")).InnerHTML(); inner != script {
+		t.Fatalf("expected script content verbatim, got: %s", inner)
+	}
+	if inner := doc.Root().Find(HasName("style")).InnerHTML(); inner != "a > b {}" {
+		t.Fatalf("expected style content verbatim, got: %s", inner)
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	div := root.Find(HasID("root"))
+	p := div.Find(HasName("p"))
+
+	div.Empty()
+	if count := div.ChildrenCount(); count != 0 {
+		t.Fatalf("expected no children, got %d", count)
+	}
+	if text := div.String(); text != `<div id="root" class="container"></div>` {
+		t.Fatalf("expected empty div, got %s", text)
+	}
+	if p.Parent() != nil {
+		t.Fatalf("expected removed children to be detached")
+	}
+	if found := root.Find(HasName("p")); found != nil {
+		t.Fatalf("expected removed children not to be found, got %v", found)
+	}
+
+	div.Empty()
+	if text := div.String(); text != `<div id="root" class="container"></div>` {
+		t.Fatalf("expected Empty to be idempotent, got %s", text)
+	}
+}
+
+func TestSetInnerHTML(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	p := root.Find(AttrEq("class", "a b"))
+	span := p.Find(HasName("span"))
+
+	markup := `Bye <b>now</b><i>!</i>`
+	if err := p.SetInnerHTML(markup); err != nil {
+		t.Fatalf("SetInnerHTML error: %v", err)
+	}
+	if inner := p.InnerHTML(); inner != markup {
+		t.Fatalf("expected round trip, got: %s", inner)
+	}
+	if span.Parent() != nil {
+		t.Fatalf("expected old children to be detached")
+	}
+	if b := root.Find(HasName("b")); b == nil || b.Parent() != p {
+		t.Fatalf("expected new children to be searchable")
+	}
+
+	if err := p.SetInnerHTML("<em>unclosed"); err != nil {
+		t.Fatalf("SetInnerHTML error: %v", err)
+	}
+	if inner := p.InnerHTML(); inner != "<em>unclosed</em>" {
+		t.Fatalf("expected malformed markup to be recovered, got: %s", inner)
+	}
+
+	doc, err = ParseString(`<table><tbody></tbody></table>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	tbody := doc.Root().Find(HasName("tbody"))
+	if err := tbody.SetInnerHTML("<tr><td>cell</td></tr>"); err != nil {
+		t.Fatalf("SetInnerHTML error: %v", err)
+	}
+	if td := tbody.Find(HasName("td")); td == nil || td.Text() != "cell" {
+		t.Fatalf("expected markup to be parsed in element context")
+	}
+
+	if err := NewTag("br", nil).SetInnerHTML("text"); err == nil {
+		t.Fatalf("expected error for void element")
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
 func TestFind(t *testing.T) {
 	doc, err := ParseString(sampleHTML)
 	if err != nil {
@@ -282,6 +507,170 @@ func TestFindAllWithPredicate(t *testing.T) {
 	}
 }
 
+func TestFindAllAny(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	found := root.FindAllAny(HasName("p"), HasClass("b"), HasName("span"))
+	expected := []string{"p", "span", "p", "p"}
+	if len(found) != len(expected) {
+		t.Fatalf("expected %d tags without duplicates, got %d", len(expected), len(found))
+	}
+	for i, tag := range found {
+		if tag.Name != expected[i] {
+			t.Fatalf("expected %q at index %d, got %q", expected[i], i, tag.Name)
+		}
+	}
+
+	if found := root.FindAllAny(); len(found) != 0 {
+		t.Fatalf("expected no tags without predicates, got %v", found)
+	}
+}
+
+func TestFindChildren(t *testing.T) {
+	doc, err := ParseString(`<ul><li>One</li><li class="x">Two<ul><li class="x">Nested</li></ul></li><li>Three</li></ul>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	ul := doc.Root().Find(HasName("ul"))
+
+	var texts []string
+	for _, li := range ul.FindChildren(HasName("li")) {
+		texts = append(texts, li.Text())
+	}
+	if strings.Join(texts, ",") != "One,Two,Three" {
+		t.Fatalf("expected only direct items, got %q", texts)
+	}
+
+	if li := ul.FindChild(HasClass("x")); li == nil || li.Text() != "Two" {
+		t.Fatalf("expected first direct item with class, got %v", li)
+	}
+	if li := ul.FindChild(HasName("ul")); li != nil {
+		t.Fatalf("expected nested list not to be found, got %v", li)
+	}
+	if found := ul.FindChildren(HasName("ul")); len(found) != 0 {
+		t.Fatalf("expected no direct lists, got %v", found)
+	}
+
+	doc, err = ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	div := doc.Root().Find(HasID("root"))
+
+	found := div.FindChildren(HasName("p"))
+	if len(found) != 2 {
+		t.Fatalf("expected 2 paragraphs, got %d", len(found))
+	}
+	for i, p := range found {
+		if p != div.Children()[i] {
+			t.Fatalf("expected direct child at index %d, got %v", i, p)
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	for _, predicate := range []Predicate{HasName("p"), HasClass("b"), HasName("video"), HasName("html")} {
+		if count, expected := root.Count(predicate), len(root.FindAll(predicate)); count != expected {
+			t.Fatalf("expected Count to match FindAll length %d, got %d", expected, count)
+		}
+	}
+}
+
+func TestFindAllLimit(t *testing.T) {
+	doc, err := ParseString("<div>" + strings.Repeat("<p>item</p>", 100) + "</div>")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	checked := 0
+	predicate := func(tag *Tag) bool {
+		checked++
+		return tag.Name == "p"
+	}
+
+	found := root.FindAllLimit(predicate, 5)
+	if len(found) != 5 {
+		t.Fatalf("expected 5 paragraphs, got %d", len(found))
+	}
+	// head, body, div and the first five paragraphs
+	if checked != 8 {
+		t.Fatalf("expected traversal to stop after 8 checks, got %d", checked)
+	}
+
+	for _, limit := range []int{0, -1} {
+		if found := root.FindAllLimit(HasName("p"), limit); len(found) != 100 {
+			t.Fatalf("expected limit %d to return all 100 paragraphs, got %d", limit, len(found))
+		}
+	}
+}
+
+func TestFindAllSeq(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	expected := root.FindAll(HasName("p"))
+	index := 0
+	for p := range root.FindAllSeq(HasName("p")) {
+		if index >= len(expected) {
+			t.Fatalf("more iterations than expected")
+		}
+		if p != expected[index] {
+			t.Fatalf("expected FindAllSeq to match FindAll at index %d", index)
+		}
+		index++
+	}
+	if index != len(expected) {
+		t.Fatalf("expected %d matches, got %d", len(expected), index)
+	}
+}
+
+func TestFindAllSeqBreak(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	checked := 0
+	predicate := func(tag *Tag) bool {
+		checked++
+		return tag.Name == "p"
+	}
+
+	for p := range root.FindAllSeq(predicate) {
+		if !HasClass("a")(p) {
+			t.Fatalf("expected first paragraph, got %s", p.String())
+		}
+		break
+	}
+
+	// head, body, div and the first paragraph
+	if checked != 4 {
+		t.Fatalf("expected traversal to stop after 4 checks, got %d", checked)
+	}
+}
+
 func TestFindParent(t *testing.T) {
 	doc, err := ParseString(sampleHTML)
 	if err != nil {
@@ -323,6 +712,53 @@ func TestFindParentNotFound(t *testing.T) {
 	}
 }
 
+func TestClosest(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	span := root.Find(HasName("span"))
+	if span == nil {
+		t.Fatalf("could not find span")
+	}
+
+	if found := span.Closest(HasName("span")); found != span {
+		t.Fatalf("expected Closest to return the tag itself, got %v", found)
+	}
+	if found := span.Closest(HasClass("b")); found == nil || found != span.Parent() {
+		t.Fatalf("expected Closest to return the parent p, got %v", found)
+	}
+	if found := span.Closest(HasName("div")); found == nil || found.Attrs["id"] != "root" {
+		t.Fatalf("expected Closest to return the root div, got %v", found)
+	}
+	if found := span.Closest(HasName("video")); found != nil {
+		t.Fatalf("expected nil for no match, got %v", found)
+	}
+}
+
+func TestFindParentChecksEachAncestorOnce(t *testing.T) {
+	doc, err := ParseString(strings.Repeat("<div>", 50) + "<span></span>")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	span := doc.Root().Find(HasName("span"))
+
+	checked := 0
+	span.FindParent(func(*Tag) bool {
+		checked++
+		return false
+	})
+
+	// 50 divs, body and html
+	if checked != 52 {
+		t.Fatalf("expected 52 checks, got %d", checked)
+	}
+}
+
 func TestUnwrap(t *testing.T) {
 	doc, err := ParseString(sampleHTML)
 	if err != nil {
@@ -349,23 +785,645 @@ func TestUnwrap(t *testing.T) {
 	}
 }
 
-func TestIterNodes(t *testing.T) {
-    doc, err := ParseString(`<div>Text with <a>inner</a> tag</div>`)
-    if err != nil {
-        t.Fatalf("Parse error: %v", err)
-    }
+func TestRemove(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
 
-    root := doc.Root()
+	root := doc.Root()
 
-    div := root.Find(HasName("div"))
-	if div == nil {
-		t.Fatalf("could not find div")
+	p := root.Find(HasClass("a"))
+	if p == nil {
+		t.Fatalf("could not find p.a")
 	}
 
-    tagIndex := 0
-    exptectedTexts := []string{"Text with ", "inner", " tag"}
-    for node := range div.IterNodes() {
-        if tagIndex > 2 {
+	p.Remove()
+
+	if root.Find(HasClass("a")) != nil {
+		t.Fatalf("p should not exist after remove")
+	}
+	if root.Find(HasName("span")) != nil {
+		t.Fatalf("content of p should be removed with it")
+	}
+
+	p.Remove()
+	if text := p.String(); text != `<p class="a b">Hello <span>World</span></p>` {
+		t.Fatalf("expected removed subtree to stay intact, got %s", text)
+	}
+}
+
+func TestUnwrapKeepsChildren(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	article := root.Find(HasName("article"))
+	if article == nil {
+		t.Fatalf("could not find article")
+	}
+
+	article.Unwrap()
+
+	if root.Find(HasName("article")) != nil {
+		t.Fatalf("article should not exist after unwrap")
+	}
+
+	h1 := root.Find(HasName("h1"))
+	if h1 == nil {
+		t.Fatalf("children of article should stay after unwrap")
+	}
+	if h1.Parent().Attrs["id"] != "root" {
+		t.Fatalf("expected children to be moved to div#root, got %q", h1.Parent().Name)
+	}
+}
+
+func TestUnwrapRoundTrip(t *testing.T) {
+	doc, err := ParseString(`<p>a <b><i>x</i> y</b> z</p>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	b := root.Find(HasName("b"))
+	if b == nil {
+		t.Fatalf("could not find b")
+	}
+
+	b.Unwrap()
+
+	p := root.Find(HasName("p"))
+	if text := p.String(); text != `<p>a <i>x</i> y z</p>` {
+		t.Fatalf("unexpected rendering after unwrap: %s", text)
+	}
+
+	// Detached tag has no parent to receive children
+	b.Unwrap()
+	if text := p.String(); text != `<p>a <i>x</i> y z</p>` {
+		t.Fatalf("expected unwrap of detached tag to be no-op, got %s", text)
+	}
+}
+
+func TestAppendChild(t *testing.T) {
+	doc, err := ParseString(`<ul><li>one</li></ul><div><li>two</li></div>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	ul := root.Find(HasName("ul"))
+	li := root.Find(HasName("div")).Find(HasName("li"))
+
+	if err := ul.AppendChild(li); err != nil {
+		t.Fatalf("AppendChild error: %v", err)
+	}
+
+	if text := ul.String(); text != `<ul><li>one</li><li>two</li></ul>` {
+		t.Fatalf("unexpected rendering after AppendChild: %s", text)
+	}
+	if text := root.Find(HasName("div")).String(); text != `<div></div>` {
+		t.Fatalf("expected child to be detached from previous parent, got %s", text)
+	}
+	if ul.Children()[1] != li {
+		t.Fatalf("expected appended tag to be reachable from new parent")
+	}
+}
+
+func TestPrependChild(t *testing.T) {
+	doc, err := ParseString(`<ul><li>two</li></ul>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	other, err := ParseString(`<ol><li>one</li></ol>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	ul := doc.Root().Find(HasName("ul"))
+	li := other.Root().Find(HasName("li"))
+
+	if err := ul.PrependChild(li); err != nil {
+		t.Fatalf("PrependChild error: %v", err)
+	}
+
+	if text := ul.String(); text != `<ul><li>one</li><li>two</li></ul>` {
+		t.Fatalf("unexpected rendering after PrependChild: %s", text)
+	}
+	if ul.FirstChild() != li {
+		t.Fatalf("expected prepended tag from other document to keep its identity")
+	}
+	if li.Parent() != ul {
+		t.Fatalf("expected prepended tag to have new parent")
+	}
+}
+
+func TestAppendChildCycle(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	div := root.Find(HasID("root"))
+	span := root.Find(HasName("span"))
+
+	if err := span.AppendChild(div); err == nil {
+		t.Fatalf("expected error when appending an ancestor")
+	}
+	if err := div.PrependChild(div); err == nil {
+		t.Fatalf("expected error when prepending a tag to itself")
+	}
+	if err := div.AppendChild(nil); err == nil {
+		t.Fatalf("expected error when appending nil")
+	}
+	if span.Parent().Parent() != div {
+		t.Fatalf("expected tree to stay unchanged after failed insert")
+	}
+}
+
+func TestAppendChildVoidElement(t *testing.T) {
+	br := NewTag("br", nil)
+	italic := NewTag("i", nil)
+
+	if err := br.AppendChild(italic); err == nil {
+		t.Fatalf("expected error when appending to a void element")
+	}
+	if err := br.PrependChild(italic); err == nil {
+		t.Fatalf("expected error when prepending to a void element")
+	}
+	if text := br.String(); text != "<br/>" {
+		t.Fatalf("expected void element to stay empty, got %s", text)
+	}
+	if italic.Parent() != nil {
+		t.Fatalf("expected child to stay detached after failed insert")
+	}
+}
+
+func TestInsertBefore(t *testing.T) {
+	doc, err := ParseString(`<ul><li>two</li><li>three</li><li>one</li></ul>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	ul := root.Find(HasName("ul"))
+	items := ul.Children()
+
+	if err := items[0].InsertBefore(items[2]); err != nil {
+		t.Fatalf("InsertBefore error: %v", err)
+	}
+	if text := ul.String(); text != `<ul><li>one</li><li>two</li><li>three</li></ul>` {
+		t.Fatalf("unexpected rendering after InsertBefore: %s", text)
+	}
+}
+
+func TestInsertAfter(t *testing.T) {
+	doc, err := ParseString(`<ul><li>three</li><li>one</li><li>two</li></ul>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	ul := root.Find(HasName("ul"))
+	items := ul.Children()
+
+	if err := items[2].InsertAfter(items[0]); err != nil {
+		t.Fatalf("InsertAfter error: %v", err)
+	}
+	if text := ul.String(); text != `<ul><li>one</li><li>two</li><li>three</li></ul>` {
+		t.Fatalf("unexpected rendering after InsertAfter: %s", text)
+	}
+	if items[2].Next() != items[0] {
+		t.Fatalf("expected inserted tag to follow the receiver")
+	}
+}
+
+func TestInsertErrors(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	span := root.Find(HasName("span"))
+	p := root.Find(HasClass("a"))
+
+	if err := span.InsertBefore(p); err == nil {
+		t.Fatalf("expected error when inserting an ancestor next to its descendant")
+	}
+	if err := span.InsertAfter(span); err == nil {
+		t.Fatalf("expected error when inserting a tag next to itself")
+	}
+
+	p.Remove()
+	if err := p.InsertAfter(root.Find(HasName("h1"))); err == nil {
+		t.Fatalf("expected error when receiver has no parent")
+	}
+}
+
+func TestReplaceWith(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	other, err := ParseString(`<b>Gopher</b>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	span := root.Find(HasName("span"))
+	b := other.Root().Find(HasName("b"))
+
+	if err := span.ReplaceWith(b); err != nil {
+		t.Fatalf("ReplaceWith error: %v", err)
+	}
+
+	p := root.Find(HasClass("a"))
+	if text := p.String(); text != `<p class="a b">Hello <b>Gopher</b></p>` {
+		t.Fatalf("unexpected rendering after ReplaceWith: %s", text)
+	}
+	if root.Find(HasName("b")) != b {
+		t.Fatalf("expected replacement to be reachable from the document")
+	}
+
+	if err := span.ReplaceWith(root.Find(HasName("h1"))); err == nil {
+		t.Fatalf("expected error when replacing a detached tag")
+	}
+}
+
+func TestModifyRoot(t *testing.T) {
+	doc, err := ParseString(`<p>text</p>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	main := NewTag("main", nil)
+	if err := doc.Root().ReplaceWith(main); err != nil {
+		t.Fatalf("ReplaceWith error: %v", err)
+	}
+	if doc.Root() != main {
+		t.Fatalf("expected replacement to become root, got %s", doc.Root().Name)
+	}
+
+	doc, err = ParseString(`<p>text</p>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	doc.Root().Unwrap()
+	if root := doc.Root(); root == nil || root.Name != "head" {
+		t.Fatalf("expected first unwrapped child to become root, got %v", root)
+	}
+
+	doc.Root().Remove()
+	if root := doc.Root(); root == nil || root.Name != "body" {
+		t.Fatalf("expected next element to become root, got %v", root)
+	}
+
+	doc.Root().Remove()
+	if root := doc.Root(); root != nil {
+		t.Fatalf("expected no root in empty document, got %s", root.Name)
+	}
+}
+
+func TestWrapWith(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	p := root.Find(AttrEq("class", "b"))
+	wrapper := NewTag("div", map[string]string{"class": "wrapper"})
+
+	if err := p.WrapWith(wrapper); err != nil {
+		t.Fatalf("WrapWith error: %v", err)
+	}
+
+	div := root.Find(HasID("root"))
+	if children := div.Children(); len(children) != 3 || children[1] != wrapper {
+		t.Fatalf("expected wrapper at the position of wrapped tag, got %v", children)
+	}
+	if text := wrapper.String(); text != `<div class="wrapper"><p class="b">Second</p></div>` {
+		t.Fatalf("unexpected rendering after WrapWith: %s", text)
+	}
+	if p.Parent() != wrapper || root.Find(HasClass("wrapper")) != wrapper {
+		t.Fatalf("expected wrapped tag and wrapper to be reachable from the document")
+	}
+
+	span := root.Find(HasName("span"))
+	if err := span.WrapWith(div); err == nil {
+		t.Fatalf("expected error when wrapping with an ancestor")
+	}
+	if err := div.WrapWith(span); err == nil {
+		t.Fatalf("expected error when wrapping with a descendant")
+	}
+	if err := span.WrapWith(NewTag("br", nil)); err == nil {
+		t.Fatalf("expected error when wrapping with a void element")
+	}
+	if err := NewTag("b", nil).WrapWith(NewTag("i", nil)); err == nil {
+		t.Fatalf("expected error when wrapping a detached tag")
+	}
+	if err := span.WrapWith(nil); err == nil {
+		t.Fatalf("expected error when wrapping with nil")
+	}
+	if text := root.Find(AttrEq("class", "a b")).String(); text != `<p class="a b">Hello <span>World</span></p>` {
+		t.Fatalf("expected failed WrapWith to leave tree unchanged, got: %s", text)
+	}
+}
+
+func TestGetAttr(t *testing.T) {
+	doc, err := ParseString(`<input name="q" disabled>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	input := root.Find(HasName("input"))
+	if input == nil {
+		t.Fatalf("could not find input")
+	}
+
+	if value, ok := input.GetAttr("name"); !ok || value != "q" {
+		t.Fatalf("expected name 'q', got %q (present: %v)", value, ok)
+	}
+	if value, ok := input.GetAttr("disabled"); !ok || value != "" {
+		t.Fatalf("expected present empty disabled, got %q (present: %v)", value, ok)
+	}
+	if value, ok := input.GetAttr("readonly"); ok {
+		t.Fatalf("expected readonly to be absent, got %q", value)
+	}
+}
+
+func TestDataAttrs(t *testing.T) {
+	doc, err := ParseString(`<div data-id="5" data-role="row" data-user-name="ann" id="x"></div><p></p>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	div := root.Find(HasName("div"))
+	data := div.DataAttrs()
+	expected := map[string]string{"id": "5", "role": "row", "user-name": "ann"}
+	if len(data) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, data)
+	}
+	for key, value := range expected {
+		if data[key] != value {
+			t.Fatalf("expected %s=%q, got %q", key, value, data[key])
+		}
+	}
+
+	if value, ok := div.Data("role"); !ok || value != "row" {
+		t.Fatalf("expected data-role 'row', got %q (present: %v)", value, ok)
+	}
+	if value, ok := div.Data("missing"); ok {
+		t.Fatalf("expected data-missing to be absent, got %q", value)
+	}
+
+	if data := root.Find(HasName("p")).DataAttrs(); data == nil || len(data) != 0 {
+		t.Fatalf("expected empty map, got %v", data)
+	}
+}
+
+func TestAttrsOrdered(t *testing.T) {
+	doc, err := ParseString(`<input type="text" name="q" id="search" disabled><br>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	expected := []Attr{
+		{Key: "type", Val: "text"},
+		{Key: "name", Val: "q"},
+		{Key: "id", Val: "search"},
+		{Key: "disabled", Val: ""},
+	}
+	attrs := root.Find(HasName("input")).AttrsOrdered()
+	if len(attrs) != len(expected) {
+		t.Fatalf("expected %d attributes, got %d", len(expected), len(attrs))
+	}
+	for i, attr := range attrs {
+		if attr != expected[i] {
+			t.Fatalf("expected %v at index %d, got %v", expected[i], i, attr)
+		}
+	}
+
+	if attrs := root.Find(HasName("br")).AttrsOrdered(); attrs == nil || len(attrs) != 0 {
+		t.Fatalf("expected empty slice, got %v", attrs)
+	}
+}
+
+func TestOpenTag(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	if text := root.Find(HasID("root")).OpenTag(); text != `<div id="root" class="container">` {
+		t.Fatalf("unexpected open tag: %s", text)
+	}
+	if text := root.Find(HasName("span")).OpenTag(); text != `<span>` {
+		t.Fatalf("unexpected open tag: %s", text)
+	}
+
+	img := NewTag("img", map[string]string{"src": "a.png", "alt": `"quoted"`})
+	if text := img.OpenTag(); text != img.String() {
+		t.Fatalf("expected void open tag to match String, got %s", text)
+	}
+}
+
+func TestAttrNamespaces(t *testing.T) {
+	doc, err := ParseString(`<svg><a xlink:href="#icon" href="/plain"></a></svg>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	link := doc.Root().Find(HasName("a"))
+	if link == nil {
+		t.Fatalf("could not find link")
+	}
+
+	if value, ok := link.GetAttrNS("xlink", "href"); !ok || value != "#icon" {
+		t.Fatalf("expected xlink:href '#icon', got %q (present: %v)", value, ok)
+	}
+	if value, ok := link.GetAttrNS("", "href"); !ok || value != "/plain" {
+		t.Fatalf("expected plain href '/plain', got %q (present: %v)", value, ok)
+	}
+	if value, ok := link.GetAttrNS("xml", "href"); ok {
+		t.Fatalf("expected xml:href to be absent, got %q", value)
+	}
+
+	attrs := link.AttrsOrdered()
+	if len(attrs) != 2 || attrs[0].Namespace != "xlink" || attrs[1].Namespace != "" {
+		t.Fatalf("expected namespaces to be preserved, got %v", attrs)
+	}
+
+	if rendered := link.String(); !strings.Contains(rendered, `xlink:href="#icon"`) {
+		t.Fatalf("expected rendered link to keep namespace, got %q", rendered)
+	}
+}
+
+func TestClassList(t *testing.T) {
+	doc, err := ParseString("<div class=\"  card\t featured\n\nbig \"></div><p></p>")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	classes := root.Find(HasName("div")).ClassList()
+	expected := []string{"card", "featured", "big"}
+	if len(classes) != len(expected) {
+		t.Fatalf("expected %d classes, got %d: %q", len(expected), len(classes), classes)
+	}
+	for i, class := range classes {
+		if class != expected[i] {
+			t.Fatalf("expected class %q at index %d, got %q", expected[i], i, class)
+		}
+	}
+
+	classes = root.Find(HasName("p")).ClassList()
+	if classes == nil || len(classes) != 0 {
+		t.Fatalf("expected empty slice for tag without class, got %v", classes)
+	}
+}
+
+func TestSetAttr(t *testing.T) {
+	doc, err := ParseString(`<a href="/page" class="link">Page</a>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	a := root.Find(HasName("a"))
+	if a == nil {
+		t.Fatalf("could not find a")
+	}
+
+	a.SetAttr("href", "https://example.com/page")
+	a.SetAttr("rel", "nofollow")
+
+	if a.Attrs["href"] != "https://example.com/page" {
+		t.Fatalf("expected Attrs to be updated, got %q", a.Attrs["href"])
+	}
+	if text := a.String(); text != `<a href="https://example.com/page" class="link" rel="nofollow">Page</a>` {
+		t.Fatalf("unexpected rendering after SetAttr: %s", text)
+	}
+}
+
+func TestRemoveAttr(t *testing.T) {
+	doc, err := ParseString(`<a href="/page" class="link">Page</a>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	a := root.Find(HasName("a"))
+	if a == nil {
+		t.Fatalf("could not find a")
+	}
+
+	a.RemoveAttr("class")
+	a.RemoveAttr("id")
+
+	if _, ok := a.Attrs["class"]; ok {
+		t.Fatalf("expected class to be removed from Attrs")
+	}
+	if text := a.String(); text != `<a href="/page">Page</a>` {
+		t.Fatalf("unexpected rendering after RemoveAttr: %s", text)
+	}
+}
+
+func TestAddClass(t *testing.T) {
+	doc, err := ParseString(`<div class="card"></div><p></p>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	div := root.Find(HasName("div"))
+	div.AddClass("active")
+	div.AddClass("active")
+	if text := div.String(); text != `<div class="card active"></div>` {
+		t.Fatalf("unexpected rendering after AddClass: %s", text)
+	}
+
+	p := root.Find(HasName("p"))
+	p.AddClass("intro")
+	if text := p.String(); text != `<p class="intro"></p>` {
+		t.Fatalf("unexpected rendering after AddClass without class: %s", text)
+	}
+}
+
+func TestRemoveClass(t *testing.T) {
+	doc, err := ParseString(`<div class="card old featured old"></div>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	div := root.Find(HasName("div"))
+	div.RemoveClass("old")
+	if text := div.String(); text != `<div class="card featured"></div>` {
+		t.Fatalf("unexpected rendering after RemoveClass: %s", text)
+	}
+
+	div.RemoveClass("card")
+	div.RemoveClass("featured")
+	if text := div.String(); text != `<div></div>` {
+		t.Fatalf("expected class attribute to be removed, got %s", text)
+	}
+	if _, ok := div.Attrs["class"]; ok {
+		t.Fatalf("expected class to be removed from Attrs")
+	}
+
+	div.RemoveClass("missing")
+	if text := div.String(); text != `<div></div>` {
+		t.Fatalf("expected RemoveClass on tag without class to be no-op, got %s", text)
+	}
+}
+
+func TestIterNodes(t *testing.T) {
+    doc, err := ParseString(`<div>Text with <a>inner</a> tag</div>`)
+    if err != nil {
+        t.Fatalf("Parse error: %v", err)
+    }
+
+    root := doc.Root()
+
+    div := root.Find(HasName("div"))
+	if div == nil {
+		t.Fatalf("could not find div")
+	}
+
+    tagIndex := 0
+    exptectedTexts := []string{"Text with ", "inner", " tag"}
+    for node := range div.IterNodes() {
+        if tagIndex > 2 {
             t.Fatal("more iterations than expected")
         }
         expected := exptectedTexts[tagIndex]
@@ -391,71 +1449,1516 @@ func TestIterNodes(t *testing.T) {
     }
 }
 
-func TestIterNodesWithOnlyText(t *testing.T) {
-	html := `<p>Just plain text</p>`
-	doc, err := ParseString(html)
+func TestIterNodesWithOnlyText(t *testing.T) {
+	html := `<p>Just plain text</p>`
+	doc, err := ParseString(html)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+    root := doc.Root()
+
+	p := root.Find(HasName("p"))
+	if p == nil {
+		t.Fatalf("could not find p")
+	}
+
+	var count int
+	var foundText string
+	for node := range p.IterNodes() {
+		count++
+		str, ok := node.(NavigableString)
+		if !ok {
+			t.Fatalf("expected NavigableString, got %T", node)
+		}
+		foundText = str.Text
+	}
+
+	if count != 1 {
+		t.Fatalf("expected 1 node, got %d", count)
+	}
+	if strings.TrimSpace(foundText) != "Just plain text" {
+		t.Fatalf("expected 'Just plain text', got %q", foundText)
+	}
+}
+
+func TestIterNodesNestedTags(t *testing.T) {
+	html := `<div><span>one</span><em>two</em><strong>three</strong></div>`
+	doc, err := ParseString(html)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+    root := doc.Root()
+
+	div := root.Find(HasName("div"))
+	if div == nil {
+		t.Fatalf("could not find div")
+	}
+
+	expectedTags := []string{"span", "em", "strong"}
+	tagIndex := 0
+
+	for node := range div.IterNodes() {
+		tag, ok := node.(*Tag)
+		if !ok {
+			t.Fatalf("expected *Tag, got %T", node)
+		}
+		if tagIndex >= len(expectedTags) {
+			t.Fatalf("too many tags: expected %d, got at least %d", len(expectedTags), tagIndex+1)
+		}
+		if tag.Name != expectedTags[tagIndex] {
+			t.Fatalf("expected tag %q at index %d, got %q", expectedTags[tagIndex], tagIndex, tag.Name)
+		}
+		tagIndex++
+	}
+
+	if tagIndex != len(expectedTags) {
+		t.Fatalf("expected %d tags, got %d", len(expectedTags), tagIndex)
+	}
+}
+
+func TestAttrsString(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	div := root.Find(HasName("div"))
+	if div == nil {
+		t.Fatalf("could not find div")
+	}
+
+	attrs := div.AttrsString()
+	if attrs != `id="root" class="container"` {
+		t.Fatalf("unexpected attributes string: %s", attrs)
+	}
+}
+
+func TestAttrsStringEscaping(t *testing.T) {
+	doc, err := ParseString(`<a title="say &quot;hi&quot; &amp; go">link</a>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	a := root.Find(HasName("a"))
+	if a == nil {
+		t.Fatalf("could not find a")
+	}
+
+	attrs := a.AttrsString()
+	if attrs != `title="say &#34;hi&#34; &amp; go"` {
+		t.Fatalf("unexpected attributes string: %s", attrs)
+	}
+}
+
+func TestNoscriptContent(t *testing.T) {
+	doc, err := ParseString(`<body><noscript><div class="fallback"><img src="a.png"></div></noscript></body>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	if root.Find(HasName("img")) != nil {
+		t.Fatalf("expected img inside noscript to be unreachable without re-parse")
+	}
+
+	noscript := root.Find(HasName("noscript"))
+	if noscript == nil {
+		t.Fatalf("could not find noscript")
+	}
+
+	content := noscript.NoscriptContent()
+	if content == nil {
+		t.Fatalf("NoscriptContent() returned nil")
+	}
+
+	img := content.Find(HasName("img"))
+	if img == nil {
+		t.Fatalf("could not find img inside noscript content")
+	}
+	if img.Attrs["src"] != "a.png" {
+		t.Fatalf("expected src 'a.png', got %q", img.Attrs["src"])
+	}
+	if img.Parent().Attrs["class"] != "fallback" {
+		t.Fatalf("expected img parent to be div.fallback")
+	}
+
+	if root.Find(HasName("body")).NoscriptContent() != nil {
+		t.Fatalf("expected nil for non-noscript tag")
+	}
+}
+
+func TestFindUntil(t *testing.T) {
+	html := `
+	<div id="page">
+		<footer>
+			<span class="price">0.00</span>
+		</footer>
+		<section>
+			<span class="price">9.99</span>
+		</section>
+	</div>
+	`
+
+	doc, err := ParseString(html)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	page := root.Find(AttrEq("id", "page"))
+	if page == nil {
+		t.Fatalf("could not find div#page")
+	}
+
+	price := page.FindUntil(HasClass("price"), HasName("footer"))
+	if price == nil {
+		t.Fatalf("FindUntil() returned nil")
+	}
+	if price.Text() != "9.99" {
+		t.Fatalf("expected price outside footer '9.99', got %q", price.Text())
+	}
+
+	found := page.FindUntil(HasName("footer"), HasName("footer"))
+	if found != nil {
+		t.Fatalf("expected stopped tag itself not to match, got %v", found)
+	}
+}
+
+func TestNextInDocument(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	expected := []string{"body", "div", "p", "span", "p", "article", "h1", "p"}
+	tag := root.Find(HasName("head"))
+	for i, name := range expected {
+		tag = tag.NextInDocument()
+		if tag == nil {
+			t.Fatalf("NextInDocument() returned nil at step %d", i)
+		}
+		if tag.Name != name {
+			t.Fatalf("expected %q at step %d, got %q", name, i, tag.Name)
+		}
+	}
+
+	if next := tag.NextInDocument(); next != nil {
+		t.Fatalf("expected nil at the end of document, got %q", next.Name)
+	}
+}
+
+func TestPrevInDocument(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	expected := []string{"h1", "article", "p", "span", "p", "div", "body", "head", "html"}
+	tag := root.FindAll(HasName("p"))[2]
+	for i, name := range expected {
+		tag = tag.PrevInDocument()
+		if tag == nil {
+			t.Fatalf("PrevInDocument() returned nil at step %d", i)
+		}
+		if tag.Name != name {
+			t.Fatalf("expected %q at step %d, got %q", name, i, tag.Name)
+		}
+	}
+
+	if prev := tag.PrevInDocument(); prev != nil {
+		t.Fatalf("expected nil at the start of document, got %q", prev.Name)
+	}
+}
+
+func TestSrcSet(t *testing.T) {
+	html := `
+	<div>
+		<img id="width" srcset="small.jpg 480w,  medium.jpg   800w,
+			large.jpg 1080w">
+		<img id="density" srcset="a.png, b.png 2x, /img,3.png 3x">
+		<img id="none" src="a.png">
+	</div>
+	`
+
+	doc, err := ParseString(html)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	tests := []struct {
+		id       string
+		expected []SrcSetCandidate
+	}{
+		{"width", []SrcSetCandidate{
+			{URL: "small.jpg", Descriptor: "480w"},
+			{URL: "medium.jpg", Descriptor: "800w"},
+			{URL: "large.jpg", Descriptor: "1080w"},
+		}},
+		{"density", []SrcSetCandidate{
+			{URL: "a.png", Descriptor: ""},
+			{URL: "b.png", Descriptor: "2x"},
+			{URL: "/img,3.png", Descriptor: "3x"},
+		}},
+	}
+
+	for _, test := range tests {
+		img := root.Find(AttrEq("id", test.id))
+		if img == nil {
+			t.Fatalf("could not find img#%s", test.id)
+		}
+
+		candidates := img.SrcSet()
+		if len(candidates) != len(test.expected) {
+			t.Fatalf("img#%s: expected %d candidates, got %d: %v", test.id, len(test.expected), len(candidates), candidates)
+		}
+		for i, candidate := range candidates {
+			if candidate != test.expected[i] {
+				t.Fatalf("img#%s: expected candidate %v at index %d, got %v", test.id, test.expected[i], i, candidate)
+			}
+		}
+	}
+
+	img := root.Find(AttrEq("id", "none"))
+	if candidates := img.SrcSet(); candidates != nil {
+		t.Fatalf("expected nil for img without srcset, got %v", candidates)
+	}
+}
+
+func TestFormAction(t *testing.T) {
+	html := `
+	<div>
+		<form id="post" method="post" action="/login"></form>
+		<form id="default" action="/search"></form>
+		<form id="invalid" method="put"></form>
+	</div>
+	`
+
+	doc, err := ParseString(html)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	tests := []struct {
+		id     string
+		method string
+		action string
+	}{
+		{"post", "POST", "/login"},
+		{"default", "GET", "/search"},
+		{"invalid", "GET", ""},
+	}
+
+	for _, test := range tests {
+		form := root.Find(AttrEq("id", test.id))
+		if form == nil {
+			t.Fatalf("could not find form#%s", test.id)
+		}
+
+		method, action := form.FormAction()
+		if method != test.method {
+			t.Fatalf("form#%s: expected method %q, got %q", test.id, test.method, method)
+		}
+		if action != test.action {
+			t.Fatalf("form#%s: expected action %q, got %q", test.id, test.action, action)
+		}
+	}
+}
+
+func TestRenderCollapsed(t *testing.T) {
+	html := `
+	<div id="content">
+		<p>
+			Hello    <b>World</b>
+		</p>
+		<pre>  keep
+		  this  </pre>
+	</div>
+	`
+
+	doc, err := ParseString(html)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	div := root.Find(AttrEq("id", "content"))
+	if div == nil {
+		t.Fatalf("could not find div#content")
+	}
+
+	var builder strings.Builder
+	if err := div.RenderCollapsed(&builder); err != nil {
+		t.Fatalf("RenderCollapsed error: %v", err)
+	}
+
+	collapsed := builder.String()
+	expected := `<div id="content"> <p> Hello <b>World</b> </p> <pre>  keep
+		  this  </pre> </div>`
+	if collapsed != expected {
+		t.Fatalf("unexpected collapsed output: %q", collapsed)
+	}
+	if len(collapsed) >= len(div.String()) {
+		t.Fatalf("expected collapsed output to be smaller: %d >= %d", len(collapsed), len(div.String()))
+	}
+	if !strings.Contains(div.String(), "\n\t\t<p>") {
+		t.Fatalf("expected original tree to stay unchanged")
+	}
+}
+
+func TestFindDescendant(t *testing.T) {
+	doc, err := ParseString(`<div class="box"><p><div class="box inner"></div></p></div><span class="box"></span>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	outer := root.Find(HasClass("box"))
+	if outer == nil {
+		t.Fatalf("could not find div.box")
+	}
+
+	found := outer.FindDescendant(HasClass("box"))
+	if found == nil {
+		t.Fatalf("FindDescendant() returned nil")
+	}
+	if found == outer {
+		t.Fatalf("FindDescendant() returned the receiver")
+	}
+	if !HasClass("inner")(found) {
+		t.Fatalf("expected inner div, got %s", found.String())
+	}
+
+	if found := found.FindDescendant(HasClass("box")); found != nil {
+		t.Fatalf("expected nil when only receiver matches, got %s", found.String())
+	}
+}
+
+func TestFindAllWithDepth(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	div := root.Find(AttrEq("id", "root"))
+	if div == nil {
+		t.Fatalf("could not find div#root")
+	}
+
+	found := div.FindAllWithDepth(Any(HasName("p"), HasName("span")))
+	expected := []struct {
+		name  string
+		depth int
+	}{
+		{"p", 1},
+		{"span", 2},
+		{"p", 1},
+		{"p", 2},
+	}
+
+	if len(found) != len(expected) {
+		t.Fatalf("expected %d matches, got %d", len(expected), len(found))
+	}
+	for i, match := range found {
+		if match.Tag.Name != expected[i].name || match.Depth != expected[i].depth {
+			t.Fatalf("expected %s at depth %d at index %d, got %s at depth %d",
+				expected[i].name, expected[i].depth, i, match.Tag.Name, match.Depth)
+		}
+		if match.Depth != match.Tag.Depth()-div.Depth() {
+			t.Fatalf("relative depth mismatch at index %d", i)
+		}
+	}
+}
+
+func TestFindByLabel(t *testing.T) {
+	html := `
+	<form>
+		<label for="email">E-mail</label>
+		<input type="hidden" name="token">
+		<input id="email" name="email">
+		<label>
+			Country
+			<select name="country"><option>US</option></select>
+		</label>
+		<label>Remember me <input type="checkbox" name="remember"></label>
+	</form>
+	`
+
+	doc, err := ParseString(html)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	tests := []struct {
+		label string
+		name  string
+	}{
+		{"E-mail", "email"},
+		{"Country", "country"},
+		{"Remember me", "remember"},
+	}
+
+	for _, test := range tests {
+		control := root.FindByLabel(test.label)
+		if control == nil {
+			t.Fatalf("FindByLabel(%q) returned nil", test.label)
+		}
+		if control.Attrs["name"] != test.name {
+			t.Fatalf("FindByLabel(%q): expected control %q, got %q", test.label, test.name, control.Attrs["name"])
+		}
+	}
+
+	if control := root.FindByLabel("Password"); control != nil {
+		t.Fatalf("expected nil for unknown label, got %s", control.String())
+	}
+}
+
+func TestFindByLabelFragment(t *testing.T) {
+	tags, err := ParseFragment(strings.NewReader(`<label for="q">Name</label><input id="q" name="query">`), NewTag("div", nil))
+	if err != nil {
+		t.Fatalf("ParseFragment error: %v", err)
+	}
+
+	control := tags[0].FindByLabel("Name")
+	if control == nil {
+		t.Fatalf("expected control for label in fragment")
+	}
+	if control != tags[1] {
+		t.Fatalf("expected sibling input, got %s", control.String())
+	}
+}
+
+func TestNilPredicate(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	span := root.Find(HasName("span"))
+	if span == nil {
+		t.Fatalf("could not find span")
+	}
+
+	tests := map[string]func(){
+		"Find":       func() { root.Find(nil) },
+		"FindAll":    func() { root.FindAll(nil) },
+		"FindParent": func() { span.FindParent(nil) },
+		"Closest":    func() { span.Closest(nil) },
+		"FindAllAny": func() { root.FindAllAny(HasName("p"), nil) },
+		"FindChild":  func() { root.FindChild(nil) },
+	}
+
+	for name, call := range tests {
+		func() {
+			defer func() {
+				r := recover()
+				if r != "gosoup: nil predicate" {
+					t.Fatalf("%s: expected nil predicate panic, got %v", name, r)
+				}
+			}()
+			call()
+		}()
+	}
+}
+
+func TestScriptAndStyleContent(t *testing.T) {
+	html := `
+	<html>
+		<head>
+			<style>p > a { color: red; }</style>
+		</head>
+		<body>
+			<script>if (a < b && c) { console.log("&amp;"); }</script>
+		</body>
+	</html>
+	`
+
+	doc, err := ParseString(html)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	script := root.Find(HasName("script"))
+	if script == nil {
+		t.Fatalf("could not find script")
+	}
+	if content := script.ScriptContent(); content != `if (a < b && c) { console.log("&amp;"); }` {
+		t.Fatalf("unexpected script content: %q", content)
+	}
+
+	style := root.Find(HasName("style"))
+	if style == nil {
+		t.Fatalf("could not find style")
+	}
+	if content := style.StyleContent(); content != `p > a { color: red; }` {
+		t.Fatalf("unexpected style content: %q", content)
+	}
+
+	if content := style.ScriptContent(); content != "" {
+		t.Fatalf("expected empty script content for style, got %q", content)
+	}
+	if content := script.StyleContent(); content != "" {
+		t.Fatalf("expected empty style content for script, got %q", content)
+	}
+}
+
+func TestFindCtx(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	found, err := root.FindCtx(context.Background(), HasName("span"))
+	if err != nil {
+		t.Fatalf("FindCtx error: %v", err)
+	}
+	if found == nil || found.Name != "span" {
+		t.Fatalf("expected to find span, got %v", found)
+	}
+}
+
+func TestFindCtxCancel(t *testing.T) {
+	html := "<div>" + strings.Repeat("<p><span>item</span></p>", 10000) + "</div>"
+
+	doc, err := ParseString(html)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	visited := 0
+	predicate := func(tag *Tag) bool {
+		visited++
+		if visited == 1000 {
+			cancel()
+		}
+		return false
+	}
+
+	found, err := root.FindCtx(ctx, predicate)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if found != nil {
+		t.Fatalf("expected nil tag on cancellation, got %v", found)
+	}
+	if visited >= 20000 {
+		t.Fatalf("expected traversal to stop early, visited %d tags", visited)
+	}
+}
+
+func TestAttrValues(t *testing.T) {
+	doc, err := ParseString(`<ul href="/self"><li><a href="/one">1</a></li><li><a>no link</a></li><li><a href="">empty</a><a href="/two">2</a></li></ul>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	ul := doc.Root().Find(HasName("ul"))
+
+	values := ul.AttrValues("href")
+	expected := []string{"/one", "", "/two"}
+	if !slices.Equal(values, expected) {
+		t.Fatalf("expected %q, got %q", expected, values)
+	}
+	if values := ul.AttrValues("src"); len(values) != 0 {
+		t.Fatalf("expected no values, got %q", values)
+	}
+}
+
+func TestComments(t *testing.T) {
+	doc, err := ParseString(`<div><!-- hi --><p>Text<!--{"id": 1}--></p></div><!-- outside -->`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	div := doc.Root().Find(HasName("div"))
+
+	comments := div.Comments()
+	if len(comments) != 2 {
+		t.Fatalf("expected 2 comments, got %d", len(comments))
+	}
+	if strings.TrimSpace(comments[0]) != "hi" {
+		t.Fatalf("expected 'hi', got %q", comments[0])
+	}
+	if comments[1] != `{"id": 1}` {
+		t.Fatalf("expected JSON comment, got %q", comments[1])
+	}
+
+	if comments := doc.Root().Find(HasName("head")).Comments(); len(comments) != 0 {
+		t.Fatalf("expected no comments, got %v", comments)
+	}
+}
+
+func TestCountText(t *testing.T) {
+	html := `
+	<div>
+		<p>go go <b>go</b>pher</p>
+		<script>var go = "go";</script>
+		<style>.go {}</style>
+	</div>
+	`
+
+	doc, err := ParseString(html)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	div := root.Find(HasName("div"))
+	if div == nil {
+		t.Fatalf("could not find div")
+	}
+
+	if count := div.CountText("go"); count != 3 {
+		t.Fatalf("expected 3 occurrences of 'go', got %d", count)
+	}
+	if count := div.CountText("gopher"); count != 0 {
+		t.Fatalf("expected no occurrence of 'gopher' across tags, got %d", count)
+	}
+	if count := div.CountText(""); count != 0 {
+		t.Fatalf("expected 0 for empty substring, got %d", count)
+	}
+}
+
+func TestCountTextElementBoundaries(t *testing.T) {
+	doc, err := ParseString(`<ul><li>ab</li><li>cd</li></ul><noscript><p>cd</p></noscript><template>cd</template>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	if count := root.CountText("bc"); count != 0 {
+		t.Fatalf("expected no match across adjacent elements, got %d", count)
+	}
+	if count := root.CountText("cd"); count != 1 {
+		t.Fatalf("expected noscript and template content to be skipped, got %d", count)
+	}
+	if count := root.CountText("<p>"); count != 0 {
+		t.Fatalf("expected raw noscript markup not to be counted, got %d", count)
+	}
+}
+
+func TestCollectMap(t *testing.T) {
+	html := `
+	<dl>
+		<dt>Color</dt><dd>Red</dd>
+		<dt>Size</dt><dd>XL</dd>
+		<dt>Color</dt><dd>Blue</dd>
+	</dl>
+	`
+
+	doc, err := ParseString(html)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	dl := root.Find(HasName("dl"))
+	if dl == nil {
+		t.Fatalf("could not find dl")
+	}
+
+	result := dl.CollectMap(
+		func(dt *Tag) string { return dt.Text() },
+		func(dt *Tag) string { return dt.Next().Text() },
+		HasName("dt"),
+	)
+
+	if len(result) != 2 {
+		t.Fatalf("expected 2 entries, got %d: %v", len(result), result)
+	}
+	if result["Size"] != "XL" {
+		t.Fatalf("expected Size 'XL', got %q", result["Size"])
+	}
+	if result["Color"] != "Blue" {
+		t.Fatalf("expected last Color 'Blue' to win, got %q", result["Color"])
+	}
+}
+
+func TestRenderedSize(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	for _, tag := range []*Tag{root, root.Find(HasName("div")), root.Find(HasName("span"))} {
+		if size := tag.RenderedSize(); size != len(tag.String()) {
+			t.Fatalf("expected size of %s to be %d, got %d", tag.Name, len(tag.String()), size)
+		}
+	}
+}
+
+func TestNextElementNamed(t *testing.T) {
+	html := `
+	<section>
+		<h2 id="start">Heading</h2>
+		<p>Intro <span>inner</span></p>
+		text
+		<span>Note</span>
+		<div id="target">Body</div>
+		<div>Other</div>
+	</section>
+	`
+
+	doc, err := ParseString(html)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	start := root.Find(AttrEq("id", "start"))
+	if start == nil {
+		t.Fatalf("could not find h2#start")
+	}
+
+	next := start.NextElementNamed("span")
+	if next == nil || next.Text() != "Note" {
+		t.Fatalf("expected span 'Note', got %v", next)
+	}
+
+	div := next.NextElementNamed("div")
+	if div == nil || div.Attrs["id"] != "target" {
+		t.Fatalf("expected div#target, got %v", div)
+	}
+
+	if found := start.NextElementNamed("article"); found != nil {
+		t.Fatalf("expected nil for missing sibling, got %v", found)
+	}
+}
+
+func TestChildrenAndAllDescendants(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	div := root.Find(AttrEq("id", "root"))
+	if div == nil {
+		t.Fatalf("could not find div#root")
+	}
+
+	children := div.Children()
+	expectedChildren := []string{"p", "p", "article"}
+	if len(children) != len(expectedChildren) {
+		t.Fatalf("expected %d direct children, got %d", len(expectedChildren), len(children))
+	}
+	for i, child := range children {
+		if child.Name != expectedChildren[i] {
+			t.Fatalf("expected child %q at index %d, got %q", expectedChildren[i], i, child.Name)
+		}
+	}
+
+	descendants := div.AllDescendants()
+	expectedDescendants := []string{"p", "span", "p", "article", "h1", "p"}
+	if len(descendants) != len(expectedDescendants) {
+		t.Fatalf("expected %d descendants, got %d", len(expectedDescendants), len(descendants))
+	}
+	for i, descendant := range descendants {
+		if descendant.Name != expectedDescendants[i] {
+			t.Fatalf("expected descendant %q at index %d, got %q", expectedDescendants[i], i, descendant.Name)
+		}
+	}
+}
+
+func TestDescendantsBFS(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	var names []string
+	for tag := range root.Find(HasID("root")).DescendantsBFS() {
+		names = append(names, tag.Name)
+	}
+
+	expected := []string{"p", "p", "article", "span", "h1", "p"}
+	if strings.Join(names, " ") != strings.Join(expected, " ") {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+
+	doc, err = ParseString(`<div><section><table id="deep"></table></section><table id="shallow"></table></div>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	for tag := range doc.Root().DescendantsBFS() {
+		if tag.Name == "table" {
+			if tag.Attrs["id"] != "shallow" {
+				t.Fatalf("expected shallowest table first, got %q", tag.Attrs["id"])
+			}
+			break
+		}
+	}
+}
+
+func TestDescendants(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	article := root.Find(HasName("article"))
+	if article == nil {
+		t.Fatalf("could not find article")
+	}
+
+	var names []string
+	for tag := range root.Find(HasID("root")).Descendants() {
+		names = append(names, tag.Name)
+		if tag == article {
+			break
+		}
+	}
+
+	expected := []string{"p", "span", "p", "article"}
+	if strings.Join(names, " ") != strings.Join(expected, " ") {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+
+	count := 0
+	for range root.Find(HasName("span")).Descendants() {
+		count++
+	}
+	if count != 0 {
+		t.Fatalf("expected no descendants for span, got %d", count)
+	}
+}
+
+func TestAncestors(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	span := root.Find(HasName("span"))
+	if span == nil {
+		t.Fatalf("could not find span")
+	}
+
+	var names []string
+	for tag := range span.Ancestors() {
+		if tag == nil {
+			t.Fatalf("Ancestors() yielded nil")
+		}
+		names = append(names, tag.Name)
+	}
+
+	expected := []string{"p", "div", "body", "html"}
+	if strings.Join(names, " ") != strings.Join(expected, " ") {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+
+	for range root.Ancestors() {
+		t.Fatalf("expected no ancestors for root")
+	}
+}
+
+func TestNextNodeAndPrevNode(t *testing.T) {
+	doc, err := ParseString(`<p>Hello <b>big</b><!-- note --><i>World</i></p>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	b := root.Find(HasName("b"))
+	if b == nil {
+		t.Fatalf("could not find b")
+	}
+
+	prev, ok := b.PrevNode().(NavigableString)
+	if !ok {
+		t.Fatalf("expected NavigableString before b, got %T", b.PrevNode())
+	}
+	if prev.Text != "Hello " {
+		t.Fatalf("expected 'Hello ', got %q", prev.Text)
+	}
+
+	next, ok := b.NextNode().(*Tag)
+	if !ok {
+		t.Fatalf("expected *Tag after b, got %T", b.NextNode())
+	}
+	if next.Name != "i" {
+		t.Fatalf("expected 'i', got %q", next.Name)
+	}
+
+	if node := next.NextNode(); node != nil {
+		t.Fatalf("expected nil at the end of siblings, got %v", node)
+	}
+	if node := root.PrevNode(); node != nil {
+		t.Fatalf("expected nil for root, got %v", node)
+	}
+}
+
+func TestNodeString(t *testing.T) {
+	doc, err := ParseString(`<p>Hello <b>World</b></p>`)
 	if err != nil {
 		t.Fatalf("Parse error: %v", err)
 	}
 
-    root := doc.Root()
+	root := doc.Root()
 
 	p := root.Find(HasName("p"))
 	if p == nil {
 		t.Fatalf("could not find p")
 	}
 
-	var count int
-	var foundText string
+	var builder strings.Builder
 	for node := range p.IterNodes() {
-		count++
-		str, ok := node.(NavigableString)
-		if !ok {
-			t.Fatalf("expected NavigableString, got %T", node)
-		}
-		foundText = str.Text
+		builder.WriteString(node.String())
 	}
 
-	if count != 1 {
-		t.Fatalf("expected 1 node, got %d", count)
+	if text := builder.String(); text != "Hello <b>World</b>" {
+		t.Fatalf("unexpected rendering of nodes: %q", text)
 	}
-	if strings.TrimSpace(foundText) != "Just plain text" {
-		t.Fatalf("expected 'Just plain text', got %q", foundText)
+}
+
+func TestFindNextSibling(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	first := root.Find(HasClass("a"))
+	if first == nil {
+		t.Fatalf("could not find p.a")
+	}
+
+	article := first.FindNextSibling(HasName("article"))
+	if article == nil {
+		t.Fatalf("FindNextSibling() returned nil")
+	}
+	if article.Name != "article" {
+		t.Fatalf("expected 'article', got %q", article.Name)
+	}
+
+	if found := first.FindNextSibling(HasName("h1")); found != nil {
+		t.Fatalf("expected FindNextSibling not to descend into siblings, got %v", found)
+	}
+	if found := first.FindNextSibling(HasClass("a")); found != nil {
+		t.Fatalf("expected FindNextSibling not to match the receiver, got %v", found)
 	}
 }
 
-func TestIterNodesNestedTags(t *testing.T) {
-	html := `<div><span>one</span><em>two</em><strong>three</strong></div>`
+func TestFindPrevSibling(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	article := root.Find(HasName("article"))
+	if article == nil {
+		t.Fatalf("could not find article")
+	}
+
+	first := article.FindPrevSibling(HasClass("a"))
+	if first == nil {
+		t.Fatalf("FindPrevSibling() returned nil")
+	}
+	if first.Text() != "Hello " {
+		t.Fatalf("expected first paragraph, got %s", first.String())
+	}
+
+	if found := article.FindPrevSibling(HasName("span")); found != nil {
+		t.Fatalf("expected FindPrevSibling not to descend into siblings, got %v", found)
+	}
+}
+
+func TestFindAllNextSiblings(t *testing.T) {
+	html := `
+	<section>
+		<p>Before</p>
+		<h2>Heading</h2>
+		<p>One</p>
+		<p>Two</p>
+		<div><p>Nested</p></div>
+		<p>Three</p>
+	</section>
+	`
+
 	doc, err := ParseString(html)
 	if err != nil {
 		t.Fatalf("Parse error: %v", err)
 	}
 
-    root := doc.Root()
+	root := doc.Root()
 
-	div := root.Find(HasName("div"))
+	h2 := root.Find(HasName("h2"))
+	if h2 == nil {
+		t.Fatalf("could not find h2")
+	}
+
+	var texts []string
+	for _, p := range h2.FindAllNextSiblings(HasName("p")) {
+		texts = append(texts, p.Text())
+	}
+
+	expected := []string{"One", "Two", "Three"}
+	if strings.Join(texts, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected %q, got %q", expected, texts)
+	}
+}
+
+func TestSiblings(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	second := root.Find(AttrEq("class", "b"))
+	siblings := second.Siblings()
+	if len(siblings) != 2 || siblings[0] != second.Prev() || siblings[1] != second.Next() {
+		t.Fatalf("expected previous p and article, got %v", siblings)
+	}
+
+	second.Remove()
+	if siblings := second.Siblings(); siblings == nil || len(siblings) != 0 {
+		t.Fatalf("expected empty slice for detached tag, got %v", siblings)
+	}
+}
+
+func TestSiblingsUntil(t *testing.T) {
+	html := `
+	<section>
+		<h2>First</h2>
+		<p>One</p>
+		<p>Two</p>
+		<h2>Second</h2>
+		<p>Three</p>
+	</section>
+	`
+
+	doc, err := ParseString(html)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	headings := doc.Root().FindAll(HasName("h2"))
+	if len(headings) != 2 {
+		t.Fatalf("expected 2 headings, got %d", len(headings))
+	}
+
+	var texts []string
+	for _, p := range headings[0].SiblingsUntil(HasName("h2")) {
+		texts = append(texts, p.Text())
+	}
+	if strings.Join(texts, ",") != "One,Two" {
+		t.Fatalf("expected paragraphs between headings, got %q", texts)
+	}
+
+	texts = nil
+	for _, p := range headings[1].SiblingsUntil(HasName("h2")) {
+		texts = append(texts, p.Text())
+	}
+	if strings.Join(texts, ",") != "Three" {
+		t.Fatalf("expected paragraphs up to end of parent, got %q", texts)
+	}
+}
+
+func TestDumpJSON(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	var builder strings.Builder
+	if err := root.Find(AttrEq("class", "a b")).DumpJSON(&builder); err != nil {
+		t.Fatalf("DumpJSON error: %v", err)
+	}
+
+	expected := `{
+  "type": "element",
+  "name": "p",
+  "attrs": {
+    "class": "a b"
+  },
+  "children": [
+    "Hello ",
+    {
+      "type": "element",
+      "name": "span",
+      "children": [
+        "World"
+      ]
+    }
+  ]
+}
+`
+	if builder.String() != expected {
+		t.Fatalf("unexpected JSON dump:\n%s", builder.String())
+	}
+
+	var first, second strings.Builder
+	if err := root.DumpJSON(&first); err != nil {
+		t.Fatalf("DumpJSON error: %v", err)
+	}
+	root.DumpJSON(&second)
+	if !json.Valid([]byte(first.String())) {
+		t.Fatalf("expected valid JSON, got:\n%s", first.String())
+	}
+	if first.String() != second.String() {
+		t.Fatalf("expected stable JSON dump")
+	}
+
+	if err := root.DumpJSON(failingWriter{}); err == nil {
+		t.Fatalf("expected write error to be returned")
+	}
+}
+
+func TestPrettyString(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	div := root.Find(HasID("root"))
 	if div == nil {
-		t.Fatalf("could not find div")
+		t.Fatalf("could not find div#root")
 	}
 
-	expectedTags := []string{"span", "em", "strong"}
-	tagIndex := 0
+	expected := `<div id="root" class="container">
+  <p class="a b">
+    Hello
+    <span>World</span>
+  </p>
+  <p class="b">Second</p>
+  <article>
+    <h1>Title</h1>
+    <p>Content</p>
+  </article>
+</div>`
 
-	for node := range div.IterNodes() {
-		tag, ok := node.(*Tag)
-		if !ok {
-			t.Fatalf("expected *Tag, got %T", node)
+	if text := div.PrettyString("  "); text != expected {
+		t.Fatalf("unexpected pretty rendering:\n%s", text)
+	}
+}
+
+func TestPrettyStringPreservesContent(t *testing.T) {
+	doc, err := ParseString("<div><pre>  a\n  b</pre><br><img src=\"a.png\"><!-- note --></div>")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	expected := "<div>\n\t<pre>  a\n  b</pre>\n\t<br/>\n\t<img src=\"a.png\"/>\n\t<!-- note -->\n</div>"
+	if text := root.Find(HasName("div")).PrettyString("\t"); text != expected {
+		t.Fatalf("unexpected pretty rendering: %q", text)
+	}
+
+	doc, err = ParseString(`<div><p>a <!-- c --> b</p><noscript><p id="n">x &amp; y</p></noscript></div>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	div := doc.Root().Find(HasName("div"))
+	expected = "<div>\n\t<p>a <!-- c --> b</p>\n\t<noscript><p id=\"n\">x &amp; y</p></noscript>\n</div>"
+	text := div.PrettyString("\t")
+	if text != expected {
+		t.Fatalf("unexpected pretty rendering: %q", text)
+	}
+
+	reparsed, err := ParseString(text)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	noscript := reparsed.Root().Find(HasName("noscript"))
+	if noscript == nil || noscript.InnerHTML() != div.Find(HasName("noscript")).InnerHTML() {
+		t.Fatalf("expected noscript content to survive re-parsing, got %v", noscript)
+	}
+}
+
+func TestContains(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	other, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	div := root.Find(HasID("root"))
+	span := root.Find(HasName("span"))
+	body := root.Find(HasName("body"))
+
+	if !div.Contains(span) {
+		t.Fatalf("expected div to contain span")
+	}
+	if !div.Contains(div) {
+		t.Fatalf("expected div to contain itself")
+	}
+	if div.Contains(body) {
+		t.Fatalf("expected div not to contain its parent")
+	}
+	if div.Contains(nil) {
+		t.Fatalf("expected div not to contain nil")
+	}
+	if div.Contains(other.Root().Find(HasName("span"))) {
+		t.Fatalf("expected div not to contain tag from another document")
+	}
+}
+
+func TestIndex(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	div := root.Find(HasID("root"))
+	for i, child := range div.Children() {
+		if index := child.Index(); index != i {
+			t.Fatalf("expected %s at index %d, got %d", child.Name, i, index)
 		}
-		if tagIndex >= len(expectedTags) {
-			t.Fatalf("too many tags: expected %d, got at least %d", len(expectedTags), tagIndex+1)
+	}
+
+	span := root.Find(HasName("span"))
+	if index := span.Index(); index != 0 {
+		t.Fatalf("expected span after text to have index 0, got %d", index)
+	}
+
+	span.Remove()
+	if index := span.Index(); index != -1 {
+		t.Fatalf("expected -1 for detached tag, got %d", index)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	div := doc.Root().Find(HasID("root"))
+
+	var events []string
+	div.Walk(func(tag *Tag) bool {
+		events = append(events, "enter "+tag.Name)
+		return tag.Name != "article"
+	}, func(tag *Tag) bool {
+		events = append(events, "exit "+tag.Name)
+		return true
+	})
+
+	expected := []string{
+		"enter div",
+		"enter p", "enter span", "exit span", "exit p",
+		"enter p", "exit p",
+		"enter article", "exit article",
+		"exit div",
+	}
+	if !slices.Equal(events, expected) {
+		t.Fatalf("expected %v, got %v", expected, events)
+	}
+
+	events = nil
+	div.Walk(nil, func(tag *Tag) bool {
+		events = append(events, tag.Name)
+		return tag.Name != "span"
+	})
+	if !slices.Equal(events, []string{"span"}) {
+		t.Fatalf("expected walk to stop after span, got %v", events)
+	}
+}
+
+func TestRenderedText(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	if text := root.Find(HasName("article")).RenderedText(); text != "Title\nContent" {
+		t.Fatalf("expected %q, got %q", "Title\nContent", text)
+	}
+	if text := root.Find(HasID("root")).RenderedText(); text != "Hello World\nSecond\nTitle\nContent" {
+		t.Fatalf("unexpected rendered text %q", text)
+	}
+
+	doc, err = ParseString("<div>One<br>Two<br><br><br>Three <b>bold</b>ly<script>skip()</script><pre>a  b\nc</pre></div>")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	expected := "One\nTwo\n\nThree boldly\na b\nc"
+	if text := doc.Root().Find(HasName("div")).RenderedText(); text != expected {
+		t.Fatalf("expected %q, got %q", expected, text)
+	}
+
+	doc, err = ParseString(`<div><noscript><p>n</p></noscript><p>t</p><template><p>hidden</p></template>v</div>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if text := doc.Root().Find(HasName("div")).RenderedText(); text != "t\nv" {
+		t.Fatalf("expected noscript and template to be skipped, got %q", text)
+	}
+
+	doc, err = ParseString(`<table><tr><th>Name</th><th>Age</th></tr><tr><td>a</td><td><b>b</b></td></tr></table>`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if text := doc.Root().Find(HasName("table")).RenderedText(); text != "Name Age\na b" {
+		t.Fatalf("expected table cells to be separated, got %q", text)
+	}
+}
+
+func TestPosition(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	tests := []struct {
+		tag      *Tag
+		expected []int
+	}{
+		{root, []int{0}},
+		{root.Find(HasName("body")), []int{0, 1}},
+		{root.Find(HasName("span")), []int{0, 1, 0, 0, 0}},
+		{root.Find(HasName("h1")), []int{0, 1, 0, 2, 0}},
+	}
+
+	for _, test := range tests {
+		if position := test.tag.Position(); !slices.Equal(position, test.expected) {
+			t.Fatalf("expected %s at %v, got %v", test.tag.Name, test.expected, position)
 		}
-		if tag.Name != expectedTags[tagIndex] {
-			t.Fatalf("expected tag %q at index %d, got %q", expectedTags[tagIndex], tagIndex, tag.Name)
+	}
+}
+
+func TestComparePosition(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	all := slices.Concat(root.FindAll(HasName("p")), root.FindAll(HasName("span")), root.FindAll(HasName("div")))
+	slices.SortFunc(all, ComparePosition)
+
+	expected := root.FindAll(Any(HasName("p"), HasName("span"), HasName("div")))
+	if !slices.Equal(all, expected) {
+		t.Fatalf("expected sorted tags to follow document order")
+	}
+
+	span := root.Find(HasName("span"))
+	if ComparePosition(span, span) != 0 {
+		t.Fatalf("expected tag to compare equal to itself")
+	}
+	if ComparePosition(span.Parent(), span) != -1 || ComparePosition(span, span.Parent()) != 1 {
+		t.Fatalf("expected ancestor to come before descendant")
+	}
+}
+
+func BenchmarkFindParent(b *testing.B) {
+	doc, err := ParseString(strings.Repeat("<div>", 500) + "<span></span>")
+	if err != nil {
+		b.Fatalf("Parse error: %v", err)
+	}
+
+	span := doc.Root().Find(HasName("span"))
+	predicate := HasName("video")
+
+	for b.Loop() {
+		span.FindParent(predicate)
+	}
+}
+
+func BenchmarkFindAll(b *testing.B) {
+	content := "<table>" + strings.Repeat(`<tr class="row"><td>a</td><td><a href="#">b</a></td></tr>`, 1000) + "</table>"
+	predicate := HasName("a")
+
+	b.ReportAllocs()
+	for b.Loop() {
+		b.StopTimer()
+		doc, err := ParseString(content)
+		if err != nil {
+			b.Fatalf("Parse error: %v", err)
 		}
-		tagIndex++
+		root := doc.Root()
+		b.StartTimer()
+
+		root.FindAll(predicate)
 	}
+}
 
-	if tagIndex != len(expectedTags) {
-		t.Fatalf("expected %d tags, got %d", len(expectedTags), tagIndex)
+func BenchmarkCount(b *testing.B) {
+	content := "<table>" + strings.Repeat(`<tr class="row"><td>a</td><td><a href="#">b</a></td></tr>`, 1000) + "</table>"
+	predicate := HasName("a")
+
+	b.ReportAllocs()
+	for b.Loop() {
+		b.StopTimer()
+		doc, err := ParseString(content)
+		if err != nil {
+			b.Fatalf("Parse error: %v", err)
+		}
+		root := doc.Root()
+		b.StartTimer()
+
+		root.Count(predicate)
 	}
 }
@@ -0,0 +1,306 @@
+package gosoup
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Find all tags matching XPath expression, in document order.
+// Supported subset: child (/) and descendant (//) steps, tag names and *,
+// and predicates [n], [@attr] and [@attr='value'].
+// Absolute expressions start at the root of a tree containing current tag,
+// relative ones start at current tag.
+// Returns empty slice and an error if expression is malformed
+func (tag *Tag) XPath(expr string) ([]*Tag, error) {
+	p := &xpathParser{input: expr}
+
+	steps, absolute, err := p.parse()
+	if err != nil {
+		return []*Tag{}, fmt.Errorf("invalid xpath %q: %w", expr, err)
+	}
+
+	start := tag.node
+	if absolute {
+		for start.Parent != nil {
+			start = start.Parent
+		}
+	}
+
+	context := []*html.Node{start}
+	for _, step := range steps {
+		context = step.apply(context)
+	}
+
+	matched := make(map[*html.Node]bool, len(context))
+	for _, node := range context {
+		matched[node] = true
+	}
+
+	result := []*Tag{}
+
+	var traverse func(*html.Node)
+	traverse = func(node *html.Node) {
+		if matched[node] {
+			result = append(result, tag.doc.newTag(node))
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			traverse(child)
+		}
+	}
+
+	traverse(start)
+
+	return result, nil
+}
+
+// Single location step of XPath expression
+type xpathStep struct {
+	descendant bool
+	name       string
+	predicates []xpathPredicate
+}
+
+// Predicate of a step, either position or attribute test
+type xpathPredicate struct {
+	position int
+	attr     string
+	value    string
+	hasValue bool
+}
+
+// Apply step to every context node, returning matched element nodes
+// without duplicates
+func (step xpathStep) apply(context []*html.Node) []*html.Node {
+	var result []*html.Node
+	seen := make(map[*html.Node]bool)
+
+	var collect func(*html.Node)
+	collect = func(parent *html.Node) {
+		for _, node := range step.children(parent) {
+			if !seen[node] {
+				seen[node] = true
+				result = append(result, node)
+			}
+		}
+
+		if !step.descendant {
+			return
+		}
+		for child := parent.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == html.ElementNode {
+				collect(child)
+			}
+		}
+	}
+
+	for _, node := range context {
+		collect(node)
+	}
+
+	return result
+}
+
+// Get children of a node matching step name and predicates.
+// Positions are counted among children matched so far
+func (step xpathStep) children(parent *html.Node) []*html.Node {
+	var nodes []*html.Node
+	for child := parent.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && (step.name == "*" || child.Data == step.name) {
+			nodes = append(nodes, child)
+		}
+	}
+
+	for _, predicate := range step.predicates {
+		if predicate.position > 0 {
+			if predicate.position > len(nodes) {
+				return nil
+			}
+			nodes = nodes[predicate.position-1 : predicate.position]
+			continue
+		}
+
+		var filtered []*html.Node
+		for _, node := range nodes {
+			if predicate.matchAttr(node) {
+				filtered = append(filtered, node)
+			}
+		}
+		nodes = filtered
+	}
+
+	return nodes
+}
+
+func (predicate xpathPredicate) matchAttr(node *html.Node) bool {
+	for _, attr := range node.Attr {
+		if attr.Namespace == "" && attr.Key == predicate.attr {
+			return !predicate.hasValue || attr.Val == predicate.value
+		}
+	}
+	return false
+}
+
+type xpathParser struct {
+	input string
+	pos   int
+}
+
+// Parse expression into steps, reporting whether it is absolute
+func (p *xpathParser) parse() ([]xpathStep, bool, error) {
+	p.input = strings.TrimSpace(p.input)
+	if p.input == "" {
+		return nil, false, fmt.Errorf("empty expression")
+	}
+
+	var steps []xpathStep
+	absolute := p.input[0] == '/'
+
+	for {
+		descendant := false
+		switch {
+		case strings.HasPrefix(p.input[p.pos:], "//"):
+			p.pos += 2
+			descendant = true
+		case strings.HasPrefix(p.input[p.pos:], "/"):
+			p.pos++
+		case len(steps) > 0:
+			return nil, false, p.unexpected()
+		}
+
+		step, err := p.parseStep()
+		if err != nil {
+			return nil, false, err
+		}
+		step.descendant = descendant
+		steps = append(steps, step)
+
+		if p.pos >= len(p.input) {
+			return steps, absolute, nil
+		}
+	}
+}
+
+// Parse name test followed by predicates
+func (p *xpathParser) parseStep() (xpathStep, error) {
+	var step xpathStep
+
+	if p.pos < len(p.input) && p.input[p.pos] == '*' {
+		p.pos++
+		step.name = "*"
+	} else if step.name = strings.ToLower(p.parseIdent()); step.name == "" {
+		return step, p.unexpected()
+	}
+
+	for p.pos < len(p.input) && p.input[p.pos] == '[' {
+		p.pos++
+		predicate, err := p.parsePredicate()
+		if err != nil {
+			return step, err
+		}
+		step.predicates = append(step.predicates, predicate)
+	}
+
+	return step, nil
+}
+
+// Parse predicate after opening bracket
+func (p *xpathParser) parsePredicate() (xpathPredicate, error) {
+	var predicate xpathPredicate
+
+	p.skipSpace()
+	if p.pos >= len(p.input) {
+		return predicate, fmt.Errorf("unterminated predicate")
+	}
+
+	if p.input[p.pos] == '@' {
+		p.pos++
+		predicate.attr = strings.ToLower(p.parseIdent())
+		if predicate.attr == "" {
+			return predicate, p.unexpected()
+		}
+		p.skipSpace()
+
+		if p.pos < len(p.input) && p.input[p.pos] == '=' {
+			p.pos++
+			p.skipSpace()
+
+			value, err := p.parseString()
+			if err != nil {
+				return predicate, err
+			}
+			predicate.value = value
+			predicate.hasValue = true
+		}
+	} else {
+		start := p.pos
+		for p.pos < len(p.input) && p.input[p.pos] >= '0' && p.input[p.pos] <= '9' {
+			p.pos++
+		}
+		if start == p.pos {
+			return predicate, p.unexpected()
+		}
+
+		position, err := strconv.Atoi(p.input[start:p.pos])
+		if err != nil || position < 1 {
+			return predicate, fmt.Errorf("invalid position %q at offset %d", p.input[start:p.pos], start)
+		}
+		predicate.position = position
+	}
+
+	p.skipSpace()
+	if p.pos >= len(p.input) {
+		return predicate, fmt.Errorf("unterminated predicate")
+	}
+	if p.input[p.pos] != ']' {
+		return predicate, p.unexpected()
+	}
+	p.pos++
+
+	return predicate, nil
+}
+
+// Parse quoted string literal
+func (p *xpathParser) parseString() (string, error) {
+	if p.pos >= len(p.input) {
+		return "", fmt.Errorf("unterminated predicate")
+	}
+
+	quote := p.input[p.pos]
+	if quote != '"' && quote != '\'' {
+		return "", p.unexpected()
+	}
+
+	end := strings.IndexByte(p.input[p.pos+1:], quote)
+	if end < 0 {
+		return "", fmt.Errorf("unterminated string at offset %d", p.pos)
+	}
+
+	value := p.input[p.pos+1 : p.pos+1+end]
+	p.pos += end + 2
+
+	return value, nil
+}
+
+func (p *xpathParser) parseIdent() string {
+	start := p.pos
+	for p.pos < len(p.input) && isIdentChar(p.input[p.pos]) {
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+func (p *xpathParser) skipSpace() {
+	for p.pos < len(p.input) && isASCIISpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *xpathParser) unexpected() error {
+	if p.pos >= len(p.input) {
+		return fmt.Errorf("unexpected end of expression")
+	}
+	return fmt.Errorf("unexpected %q at offset %d", p.input[p.pos], p.pos)
+}
@@ -0,0 +1,101 @@
+package gosoup
+
+import (
+	"testing"
+)
+
+func TestXPath(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+	div := root.Find(HasID("root"))
+
+	tests := []struct {
+		tag      *Tag
+		expr     string
+		expected []string
+	}{
+		{root, "/html/body/div", []string{"div"}},
+		{root, "/html/*", []string{"head", "body"}},
+		{root, "//p", []string{"p", "p", "p"}},
+		{root, "//div//span", []string{"span"}},
+		{root, "//div/p", []string{"p", "p"}},
+		{root, "//p[2]", []string{"p"}},
+		{root, "//p[1]", []string{"p", "p"}},
+		{root, "//p[3]", []string{}},
+		{root, "//p[@class]", []string{"p", "p"}},
+		{root, "//div[@id='root']", []string{"div"}},
+		{root, `//p[@class="a b"]/span`, []string{"span"}},
+		{root, "//div[@id='root']//p[2]", []string{"p"}},
+		{root, "//p[@class][2]", []string{"p"}},
+		{root, "//video", []string{}},
+		{div, "article/*", []string{"h1", "p"}},
+		{div, "p", []string{"p", "p"}},
+		{div, "/html/head", []string{"head"}},
+	}
+
+	for _, test := range tests {
+		found, err := test.tag.XPath(test.expr)
+		if err != nil {
+			t.Fatalf("XPath(%q) error: %v", test.expr, err)
+		}
+		if len(found) != len(test.expected) {
+			t.Fatalf("XPath(%q): expected %d tags, got %d", test.expr, len(test.expected), len(found))
+		}
+		for i, tag := range found {
+			if tag.Name != test.expected[i] {
+				t.Fatalf("XPath(%q): expected %q at index %d, got %q", test.expr, test.expected[i], i, tag.Name)
+			}
+		}
+	}
+}
+
+func TestXPathPosition(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	found, err := root.XPath("//div[@id='root']//p[2]")
+	if err != nil {
+		t.Fatalf("XPath error: %v", err)
+	}
+	if len(found) != 1 || found[0].Text() != "Second" {
+		t.Fatalf("expected second paragraph, got %v", found)
+	}
+	if found[0] != root.FindAll(HasName("p"))[1] {
+		t.Fatalf("expected XPath to return cached tag")
+	}
+
+	found, err = root.XPath("//p[1]")
+	if err != nil {
+		t.Fatalf("XPath error: %v", err)
+	}
+	if len(found) != 2 || found[0].Text() != "Hello " || found[1].Text() != "Content" {
+		t.Fatalf("expected first paragraphs of each parent in document order, got %v", found)
+	}
+}
+
+func TestXPathMalformed(t *testing.T) {
+	doc, err := ParseString(sampleHTML)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	root := doc.Root()
+
+	for _, expr := range []string{"", "/", "//", "p/", "p//", "p[", "p[]", "p[0]", "p[@]", "p[@id=]", "p[@id='x]", "p[@id='x'", "p]", "p | div", "..", "p[last()]"} {
+		found, err := root.XPath(expr)
+		if err == nil {
+			t.Fatalf("expected error for malformed expression %q", expr)
+		}
+		if found == nil || len(found) != 0 {
+			t.Fatalf("expected empty slice for malformed expression %q, got %v", expr, found)
+		}
+	}
+}